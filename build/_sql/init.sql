@@ -1,6 +1,9 @@
 CREATE TYPE difficulty_enum AS ENUM ('easy', 'medium', 'hard');
-CREATE TYPE role_enum AS ENUM ('user', 'admin');
+CREATE TYPE role_enum AS ENUM ('user', 'author', 'admin');
 CREATE TYPE status_enum AS ENUM ('accepted', 'rejected');
+CREATE TYPE scoring_enum AS ENUM ('min', 'all');
+CREATE TYPE io_mode_enum AS ENUM ('stdio', 'file');
+CREATE TYPE problem_status_enum AS ENUM ('draft', 'published', 'archived');
 
 CREATE TABLE users (
     id SERIAL PRIMARY KEY,
@@ -13,10 +16,24 @@ CREATE TABLE users (
 
 CREATE TABLE sessions (
     id SERIAL PRIMARY KEY,
-    username VARCHAR(255) UNIQUE NOT NULL,
-    token TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (username) REFERENCES users (username) ON DELETE CASCADE
+    uuid VARCHAR(255) NOT NULL UNIQUE, -- sid claim of access tokens
+    user_uuid VARCHAR(255) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL, -- prolonged on every refresh
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE
+);
+
+CREATE INDEX sessions_user_idx ON sessions (user_uuid);
+
+CREATE TABLE refresh_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY, -- SHA-256 of the token, the token itself is not stored
+    session_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    used_at TIMESTAMP, -- set on rotation, a second use revokes the session
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
 );
 
 CREATE TABLE problems (
@@ -24,7 +41,122 @@ CREATE TABLE problems (
     uuid VARCHAR(255) NOT NULL UNIQUE,
     name VARCHAR(255) NOT NULL,
     difficulty difficulty_enum NOT NULL,
-    description TEXT
+    description TEXT, -- sanitized HTML, rendered from description_source
+    description_source TEXT NOT NULL DEFAULT '', -- Markdown
+    io_mode io_mode_enum NOT NULL DEFAULT 'stdio',
+    input_file VARCHAR(64),
+    output_file VARCHAR(64),
+    current_revision INT NOT NULL DEFAULT 1,
+    status problem_status_enum NOT NULL DEFAULT 'draft',
+    publish_at TIMESTAMP, -- scheduled publication of a draft
+    publish_error TEXT NOT NULL DEFAULT '',
+    rating FLOAT, -- difficulty computed from solver data
+    rating_updated_at TIMESTAMP,
+    owner_uuid VARCHAR(255) REFERENCES users (uuid) ON DELETE SET NULL, -- NULL: only admins may edit
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('simple', regexp_replace(coalesce(description, ''), '<[^>]*>', ' ', 'g')), 'B')
+    ) STORED
+);
+
+CREATE INDEX problems_search_idx ON problems USING GIN (search_vector);
+CREATE INDEX problems_created_at_idx ON problems (created_at DESC, id DESC);
+CREATE INDEX problems_publish_at_idx ON problems (publish_at) WHERE publish_at IS NOT NULL;
+
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE
+);
+
+CREATE TABLE problem_tags (
+    problem_uuid VARCHAR(255) NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (problem_uuid, tag_id),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags (id) ON DELETE CASCADE
+);
+
+CREATE TABLE problem_revisions (
+    id SERIAL PRIMARY KEY,
+    problem_uuid VARCHAR(255) NOT NULL,
+    revision INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    difficulty difficulty_enum NOT NULL,
+    description TEXT, -- Markdown source
+    io_mode io_mode_enum NOT NULL DEFAULT 'stdio',
+    input_file VARCHAR(64),
+    output_file VARCHAR(64),
+    author_uuid VARCHAR(255),
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (problem_uuid, revision),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (author_uuid) REFERENCES users (uuid) ON DELETE SET NULL
+);
+
+CREATE TABLE subtasks (
+    id SERIAL PRIMARY KEY,
+    problem_uuid VARCHAR(255) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    points FLOAT NOT NULL CHECK (points > 0),
+    scoring scoring_enum NOT NULL DEFAULT 'min',
+    description TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE problem_coauthors (
+    problem_uuid VARCHAR(255) NOT NULL,
+    user_uuid VARCHAR(255) NOT NULL,
+    PRIMARY KEY (problem_uuid, user_uuid),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE problem_translations (
+    problem_uuid VARCHAR(255) NOT NULL,
+    locale VARCHAR(16) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '', -- sanitized HTML
+    description_source TEXT NOT NULL DEFAULT '', -- Markdown
+    input_format TEXT NOT NULL DEFAULT '',
+    input_format_source TEXT NOT NULL DEFAULT '',
+    output_format TEXT NOT NULL DEFAULT '',
+    output_format_source TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (problem_uuid, locale),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE problem_attachments (
+    id SERIAL PRIMARY KEY,
+    problem_uuid VARCHAR(255) NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    content_type VARCHAR(128) NOT NULL,
+    size BIGINT NOT NULL,
+    object_key VARCHAR(64) NOT NULL, -- SHA-256 of the content in the object store
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (problem_uuid, name),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE starter_code (
+    problem_uuid VARCHAR(255) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    code TEXT NOT NULL,
+    PRIMARY KEY (problem_uuid, language),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE author_solutions (
+    id SERIAL PRIMARY KEY,
+    problem_uuid VARCHAR(255) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    code TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending, accepted, rejected
+    checked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
 );
 
 CREATE TABLE testcases (
@@ -32,9 +164,83 @@ CREATE TABLE testcases (
     problem_uuid VARCHAR(255) NOT NULL,
     input TEXT,
     output TEXT,
+    input_key VARCHAR(64),
+    output_key VARCHAR(64),
+    input_size BIGINT NOT NULL DEFAULT 0,
+    output_size BIGINT NOT NULL DEFAULT 0,
+    subtask_id INT,
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (subtask_id) REFERENCES subtasks (id) ON DELETE SET NULL
+);
+
+CREATE TABLE teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    captain_uuid VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (captain_uuid) REFERENCES users (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE team_members (
+    team_id INT NOT NULL,
+    user_uuid VARCHAR(255) NOT NULL,
+    joined_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, user_uuid),
+    FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE team_invitations (
+    id SERIAL PRIMARY KEY,
+    team_id INT NOT NULL,
+    user_uuid VARCHAR(255) NOT NULL,
+    invited_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (team_id, user_uuid),
+    FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users (uuid) ON DELETE SET NULL
+);
+
+CREATE TABLE contests (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    freeze_time TIMESTAMP, -- later results stay hidden until revealed by the resolver
+    team_mode BOOLEAN NOT NULL DEFAULT FALSE, -- teams instead of individual users take part
+    created_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time > start_time),
+    CHECK (freeze_time IS NULL OR (freeze_time >= start_time AND freeze_time < end_time)),
+    FOREIGN KEY (created_by) REFERENCES users (uuid) ON DELETE SET NULL
+);
+
+CREATE TABLE contest_problems (
+    contest_id INT NOT NULL,
+    problem_uuid VARCHAR(255) NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (contest_id, problem_uuid),
+    FOREIGN KEY (contest_id) REFERENCES contests (id) ON DELETE CASCADE,
     FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
 );
 
+CREATE TABLE contest_participants (
+    id SERIAL PRIMARY KEY,
+    contest_id INT NOT NULL,
+    user_uuid VARCHAR(255), -- individual contests
+    team_id INT, -- team contests
+    registered_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    virtual_start TIMESTAMP, -- NULL for official participants
+    UNIQUE (contest_id, user_uuid),
+    UNIQUE (contest_id, team_id),
+    CHECK ((user_uuid IS NULL) <> (team_id IS NULL)),
+    FOREIGN KEY (contest_id) REFERENCES contests (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE
+);
+
 CREATE TABLE solutions (
     id SERIAL PRIMARY KEY,
     user_uuid VARCHAR(255) NOT NULL,
@@ -44,11 +250,138 @@ CREATE TABLE solutions (
     code TEXT NOT NULL,
     language VARCHAR(255) NOT NULL,
     status status_enum NOT NULL,
+    score FLOAT NOT NULL DEFAULT 0,
+    max_score FLOAT NOT NULL DEFAULT 100,
+    subtask_scores JSONB NOT NULL DEFAULT '[]',
+    problem_revision INT,
+    contest_id INT,
+    team_id INT, -- team the submission was made for in a team contest
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (contest_id) REFERENCES contests (id) ON DELETE SET NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE SET NULL
+);
+
+CREATE INDEX solutions_contest_idx ON solutions (contest_id, created_at) WHERE contest_id IS NOT NULL;
+
+CREATE TABLE contest_clarifications (
+    id SERIAL PRIMARY KEY,
+    contest_id INT NOT NULL,
+    problem_uuid VARCHAR(255),
+    user_uuid VARCHAR(255), -- NULL for announcements
+    question TEXT NOT NULL DEFAULT '',
+    answer TEXT,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    answered_by VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    answered_at TIMESTAMP,
+    FOREIGN KEY (contest_id) REFERENCES contests (id) ON DELETE CASCADE,
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE SET NULL,
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (answered_by) REFERENCES users (uuid) ON DELETE SET NULL
+);
+
+CREATE INDEX contest_clarifications_contest_idx ON contest_clarifications (contest_id, created_at DESC);
+
+CREATE TABLE contest_reveals (
+    contest_id INT NOT NULL,
+    solution_id INT NOT NULL,
+    revealed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (contest_id, solution_id),
+    FOREIGN KEY (contest_id) REFERENCES contests (id) ON DELETE CASCADE,
+    FOREIGN KEY (solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
+
+CREATE TABLE code_drafts (
+    user_uuid VARCHAR(255) NOT NULL,
+    problem_uuid VARCHAR(255) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    code TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_uuid, problem_uuid, language),
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE solution_similarities (
+    id SERIAL PRIMARY KEY,
+    problem_uuid VARCHAR(255) NOT NULL,
+    language VARCHAR(255) NOT NULL,
+    first_solution_id INT NOT NULL,
+    second_solution_id INT NOT NULL,
+    similarity FLOAT NOT NULL,
+    fragments JSONB NOT NULL DEFAULT '[]',
+    computed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (first_solution_id, second_solution_id),
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (first_solution_id) REFERENCES solutions (id) ON DELETE CASCADE,
+    FOREIGN KEY (second_solution_id) REFERENCES solutions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX solution_similarities_problem_idx ON solution_similarities (problem_uuid, similarity DESC);
+
+CREATE TABLE editorials (
+    problem_uuid VARCHAR(255) PRIMARY KEY,
+    content TEXT NOT NULL, -- sanitized HTML
+    content_source TEXT NOT NULL, -- Markdown
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE hints (
+    id SERIAL PRIMARY KEY,
+    problem_uuid VARCHAR(255) NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    content TEXT NOT NULL, -- sanitized HTML
+    content_source TEXT NOT NULL, -- Markdown
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE hint_reveals (
+    user_uuid VARCHAR(255) NOT NULL,
+    hint_id INT NOT NULL,
+    revealed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_uuid, hint_id),
+    FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE,
+    FOREIGN KEY (hint_id) REFERENCES hints (id) ON DELETE CASCADE
+);
+
+CREATE TABLE give_ups (
+    user_uuid VARCHAR(255) NOT NULL,
+    problem_uuid VARCHAR(255) NOT NULL,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_uuid, problem_uuid),
     FOREIGN KEY (user_uuid) REFERENCES users (uuid) ON DELETE CASCADE,
     FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
 );
 
+CREATE TABLE collections (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE collection_problems (
+    collection_id INT NOT NULL,
+    problem_uuid VARCHAR(255) NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (collection_id, problem_uuid),
+    FOREIGN KEY (collection_id) REFERENCES collections (id) ON DELETE CASCADE,
+    FOREIGN KEY (problem_uuid) REFERENCES problems (uuid) ON DELETE CASCADE
+);
+
+CREATE TABLE collection_prerequisites (
+    collection_id INT NOT NULL,
+    prerequisite_id INT NOT NULL,
+    PRIMARY KEY (collection_id, prerequisite_id),
+    CHECK (collection_id <> prerequisite_id),
+    FOREIGN KEY (collection_id) REFERENCES collections (id) ON DELETE CASCADE,
+    FOREIGN KEY (prerequisite_id) REFERENCES collections (id) ON DELETE CASCADE
+);
+
 INSERT INTO users (uuid, username, role, password)
 VALUES ('admin', 'admin', 'admin', '$2a$10$yCz84qAx0a8/w4cy8GTCkeDu5Uwqo2fEf5Gs5wKZce3pc.LZPVoSu');
 
@@ -409,4 +742,15 @@ a', 'false'),
 ('d1e0ae98-2b20-47b8-b51d-5a0dac102334', 'adceb
 *a*b', 'true'),
 ('d1e0ae98-2b20-47b8-b51d-5a0dac102334', 'acdcb
-a*c?b', 'false');
\ No newline at end of file
+a*c?b', 'false');
+
+-- Условия из сида написаны на HTML, который Markdown пропускает как есть
+UPDATE problems SET description_source = description WHERE description_source = '';
+
+-- Задачи из сида сразу опубликованы
+UPDATE problems SET status = 'published';
+
+-- Начальные ревизии для задач из сида
+INSERT INTO problem_revisions (problem_uuid, revision, name, difficulty, description, io_mode, input_file, output_file, comment)
+SELECT uuid, current_revision, name, difficulty, description_source, io_mode, input_file, output_file, 'initial'
+FROM problems;
@@ -1,11 +1,15 @@
 package application
 
 import (
+	"context"
 	"diplom/config"
 	"diplom/internal/auth"
+	"diplom/internal/contests"
 	"diplom/internal/controllers"
+	"diplom/internal/plagiarism"
 	"diplom/internal/problems"
 	"diplom/internal/repo"
+	"diplom/internal/teams"
 	"diplom/internal/user"
 
 	"github.com/gin-gonic/gin"
@@ -27,9 +31,9 @@ func InitApplication() (*Application, error) {
 	if err != nil {
 		return nil, err
 	}
-	config := zap.NewProductionConfig()
-	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
-	logger, err := config.Build()
+	logConfig := zap.NewProductionConfig()
+	logConfig.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	logger, err := logConfig.Build()
 	if err != nil {
 		return nil, err
 	}
@@ -37,16 +41,24 @@ func InitApplication() (*Application, error) {
 	if err != nil {
 		return nil, err
 	}
+	plagiarismService := plagiarism.NewService(pgClient, logger, config.CFG.Plagiarism)
 	app := &Application{
 		Handlers: controllers.Handlers{
-			AuthService:    auth.NewAuthService(pgClient, logger),
-			ProblemService: problemService,
-			UserService:    user.NewUserService(pgClient, logger),
-			Logger:         logger.Named("handlers"),
+			AuthService:       auth.NewAuthService(pgClient, pgClient, logger),
+			ProblemService:    problemService,
+			UserService:       user.NewUserService(pgClient, logger),
+			PlagiarismService: plagiarismService,
+			ContestService:    contests.NewService(pgClient, problemService, logger),
+			TeamService:       teams.NewService(pgClient, logger),
+			Logger:            logger.Named("handlers"),
 		},
 		Logger: logger,
 	}
 	app.InitServer()
 
+	go plagiarismService.Run(context.Background())
+	go problemService.RunPublishScheduler(context.Background())
+	go problemService.RunRatingUpdater(context.Background())
+
 	return app, nil
 }
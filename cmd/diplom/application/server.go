@@ -1,6 +1,7 @@
 package application
 
 import (
+	"diplom/internal/auth"
 	"diplom/internal/controllers"
 	"time"
 
@@ -27,35 +28,143 @@ func (app *Application) InitServer() {
 	{
 		authGroup.POST("/login", app.Handlers.LoginHandler)
 		authGroup.POST("/signup", app.Handlers.SignupHandler)
+		authGroup.POST("/refresh", app.Handlers.RefreshHandler)
+		authGroup.POST("/logout", app.Handlers.AuthService.AuthMiddleware(), app.Handlers.LogoutHandler)
+		authGroup.POST("/logout-all", app.Handlers.AuthService.AuthMiddleware(), app.Handlers.LogoutAllHandler)
 	}
 
+	// Вложения условий загружаются браузером напрямую, поэтому принимают и cookie сессии
+	router.GET("/api/problem/:uuid/attachments/:name",
+		app.Handlers.AuthService.CookieAuthMiddleware(), app.Handlers.GetAttachmentHandler)
+
+	// EventSource не умеет передавать заголовок Authorization
+	router.GET("/api/contest/:id/events",
+		app.Handlers.AuthService.CookieAuthMiddleware(), app.Handlers.ContestEventsHandler)
+
 	// Группа защищённых маршрутов
 	protected := router.Group("/api")
 	protected.Use(app.Handlers.AuthService.AuthMiddleware())
 	{
 		protected.GET("/profile", app.Handlers.ProfileHandler)
+		protected.GET("/sessions", app.Handlers.GetSessionsHandler)
+		protected.DELETE("/session/:id", app.Handlers.RevokeSessionHandler)
 		protected.GET("/problems", app.Handlers.GetAllProblemsHandler)
+		protected.GET("/tags", app.Handlers.GetTagsHandler)
 		protected.GET("/solutions", app.Handlers.SolutionHistoryHandler)
+		protected.GET("/collections", app.Handlers.GetCollectionsHandler)
+		protected.GET("/collection/:id", app.Handlers.GetCollectionHandler)
+		protected.GET("/teams", app.Handlers.GetTeamsHandler)
+		protected.POST("/team", app.Handlers.CreateTeamHandler)
+		teams := protected.Group("/team")
+		{
+			teams.GET("/:id", app.Handlers.GetTeamHandler)
+			teams.DELETE("/:id", app.Handlers.DeleteTeamHandler)
+			teams.POST("/:id/invitations", app.Handlers.InviteTeamMemberHandler)
+			teams.DELETE("/:id/members/:uuid", app.Handlers.RemoveTeamMemberHandler)
+		}
+		protected.POST("/invitation/:id/accept", app.Handlers.AcceptInvitationHandler)
+		protected.POST("/invitation/:id/decline", app.Handlers.DeclineInvitationHandler)
+		protected.GET("/contests", app.Handlers.GetContestsHandler)
+		contests := protected.Group("/contest")
+		{
+			contests.GET("/:id", app.Handlers.GetContestHandler)
+			contests.POST("/:id/register", app.Handlers.RegisterContestHandler)
+			contests.POST("/:id/virtual", app.Handlers.StartVirtualContestHandler)
+			contests.GET("/:id/standings", app.Handlers.GetContestStandingsHandler)
+			contests.GET("/:id/standings/virtual", app.Handlers.GetVirtualStandingsHandler)
+			contests.GET("/:id/clarifications", app.Handlers.GetClarificationsHandler)
+			contests.POST("/:id/clarifications", app.Handlers.AskClarificationHandler)
+			contests.GET("/:id/problem/:uuid", app.Handlers.GetContestProblemHandler)
+			contests.POST("/:id/problem/:uuid", app.Handlers.SubmitContestSolutionHandler)
+		}
 		problems := protected.Group("/problem")
 		{
 			problems.GET("/:uuid", app.Handlers.GetProblemHandler)
 			problems.POST("/:uuid", app.Handlers.SubmitSolutionHandler)
+			problems.GET("/:uuid/hints", app.Handlers.GetHintsHandler)
+			problems.POST("/:uuid/hints/next", app.Handlers.NextHintHandler)
+			problems.POST("/:uuid/give-up", app.Handlers.GiveUpHandler)
+			problems.GET("/:uuid/editorial", app.Handlers.GetEditorialHandler)
+			problems.GET("/:uuid/stats", app.Handlers.GetProblemStatsHandler)
+			problems.GET("/:uuid/attachments", app.Handlers.GetAttachmentsHandler)
+			problems.PUT("/:uuid/draft/:language", app.Handlers.SaveDraftHandler)
+			problems.DELETE("/:uuid/draft/:language", app.Handlers.DeleteDraftHandler)
 		}
 	}
 
-	// Группа маршрутов для администраторов
+	// Группа маршрутов для администраторов и авторов задач. Права на конкретную
+	// задачу (владелец, соавторы) проверяет ProblemAccessMiddleware.
+	authService := app.Handlers.AuthService
+	canEdit := authService.ProblemAccessMiddleware(auth.ActionEdit, "problem")
+	canDelete := authService.ProblemAccessMiddleware(auth.ActionDelete, "problem")
+	canManageAuthors := authService.ProblemAccessMiddleware(auth.ActionManageAuthors, "problem")
+	canEditResource := func(resource string) gin.HandlerFunc {
+		return authService.ProblemAccessMiddleware(auth.ActionEdit, resource)
+	}
+	canCreate := authService.PermissionMiddleware(auth.PermProblemsCreate)
+	canManageCollections := authService.PermissionMiddleware(auth.PermCollectionsManage)
+	canManageContests := authService.PermissionMiddleware(auth.PermContestsManage)
+	canManageUsers := authService.PermissionMiddleware(auth.PermUsersManage)
+
 	admin := router.Group("/api/admin")
-	admin.Use(app.Handlers.AuthService.AuthMiddleware(), app.Handlers.AuthService.RoleMiddleware("admin"))
+	admin.Use(authService.AuthMiddleware(), authService.PermissionMiddleware(auth.PermProblemsEditOwn, auth.PermProblemsEditAny))
 	{
 		admin.GET("/dashboard", controllers.AdminDashboardHandler)
 
-		admin.POST("/problem", app.Handlers.CreateProblemHandler)
-		admin.POST("/problem/:uuid/testcase", app.Handlers.AddTestcaseHandler)
+		admin.POST("/problem", canCreate, app.Handlers.CreateProblemHandler)
+		admin.POST("/problems/import", canCreate, app.Handlers.ImportProblemHandler)
+		admin.POST("/collection", canManageCollections, app.Handlers.CreateCollectionHandler)
+		admin.PUT("/collection/:id", canManageCollections, app.Handlers.UpdateCollectionHandler)
+		admin.POST("/contest", canManageContests, app.Handlers.CreateContestHandler)
+		admin.PUT("/contest/:id", canManageContests, app.Handlers.UpdateContestHandler)
+		admin.POST("/contest/:id/unfreeze", canManageContests, app.Handlers.UnfreezeContestHandler)
+		admin.POST("/contest/:id/announcements", canManageContests, app.Handlers.AnnounceHandler)
+		admin.PUT("/clarification/:id", canManageContests, app.Handlers.AnswerClarificationHandler)
+		admin.PUT("/problem/:uuid", canEdit, app.Handlers.UpdateProblemHandler)
+		admin.PUT("/problem/:uuid/tags", canEdit, app.Handlers.SetProblemTagsHandler)
+		admin.PUT("/problem/:uuid/status", canEdit, app.Handlers.SetProblemStatusHandler)
+		admin.POST("/problem/:uuid/author-solutions", canEdit, app.Handlers.AddAuthorSolutionHandler)
+		admin.GET("/problem/:uuid/author-solutions", canEdit, app.Handlers.GetAuthorSolutionsHandler)
+		admin.PUT("/problem/:uuid/editorial", canEdit, app.Handlers.SetEditorialHandler)
+		admin.GET("/problem/:uuid/editorial", canEdit, app.Handlers.GetProblemEditorialHandler)
+		admin.DELETE("/problem/:uuid/editorial", canEdit, app.Handlers.DeleteEditorialHandler)
+		admin.POST("/problem/:uuid/hints", canEdit, app.Handlers.AddHintHandler)
+		admin.GET("/problem/:uuid/hints", canEdit, app.Handlers.GetProblemHintsHandler)
+		admin.POST("/problem/:uuid/attachments", canEdit, app.Handlers.UploadAttachmentHandler)
+		admin.PUT("/problem/:uuid/starter-code/:language", canEdit, app.Handlers.SetStarterCodeHandler)
+		admin.GET("/problem/:uuid/translations", canEdit, app.Handlers.GetTranslationsHandler)
+		admin.PUT("/problem/:uuid/translations/:locale", canEdit, app.Handlers.SetTranslationHandler)
+		admin.DELETE("/problem/:uuid/translations/:locale", canEdit, app.Handlers.DeleteTranslationHandler)
+		admin.GET("/problem/:uuid/revisions", canEdit, app.Handlers.GetProblemRevisionsHandler)
+		admin.GET("/problem/:uuid/revisions/diff", canEdit, app.Handlers.DiffProblemRevisionsHandler)
+		admin.GET("/problem/:uuid/revisions/:revision", canEdit, app.Handlers.GetProblemRevisionHandler)
+		admin.POST("/problem/:uuid/revisions/:revision/restore", canEdit, app.Handlers.RestoreProblemRevisionHandler)
+		admin.POST("/problem/:uuid/testcase", canEdit, app.Handlers.AddTestcaseHandler)
+		admin.POST("/problem/:uuid/testcases/archive", canEdit, app.Handlers.UploadTestArchiveHandler)
+		admin.POST("/problem/:uuid/subtask", canEdit, app.Handlers.AddSubtaskHandler)
+		admin.GET("/problem/:uuid/coauthors", canEdit, app.Handlers.GetCoAuthorsHandler)
+		admin.POST("/problem/:uuid/coauthors", canManageAuthors, app.Handlers.AddCoAuthorHandler)
+
+		admin.GET("/problem/:uuid/testcases", canEdit, app.Handlers.GetProblemTestcasesHandler)
+		admin.GET("/testcase/:id/:kind", canEditResource("testcase"), app.Handlers.GetTestcaseDataHandler)
+		admin.GET("/problem/:uuid/subtasks", canEdit, app.Handlers.GetSubtasksHandler)
+		admin.GET("/problem/:uuid/similarity", canEdit, app.Handlers.GetProblemSimilarityHandler)
+		admin.GET("/problem/:uuid/export", canEdit, app.Handlers.ExportProblemHandler)
 
-		admin.GET("/problem/:uuid/testcases", app.Handlers.GetProblemTestcasesHandler)
+		admin.DELETE("/testcase/:id", canEditResource("testcase"), app.Handlers.DeleteTestcaseHandler)
+		admin.DELETE("/subtask/:id", canEditResource("subtask"), app.Handlers.DeleteSubtaskHandler)
+		admin.DELETE("/author-solution/:id", canEditResource("author solution"), app.Handlers.DeleteAuthorSolutionHandler)
+		admin.DELETE("/hint/:id", canEditResource("hint"), app.Handlers.DeleteHintHandler)
+		admin.DELETE("/problem/:uuid/attachments/:name", canEdit, app.Handlers.DeleteAttachmentHandler)
+		admin.DELETE("/problem/:uuid/starter-code/:language", canEdit, app.Handlers.DeleteStarterCodeHandler)
+		admin.DELETE("/problem/:uuid/coauthors/:user", canManageAuthors, app.Handlers.RemoveCoAuthorHandler)
+		admin.DELETE("/collection/:id", canManageCollections, app.Handlers.DeleteCollectionHandler)
+		admin.DELETE("/contest/:id", canManageContests, app.Handlers.DeleteContestHandler)
+		admin.DELETE("/problem/:uuid", canDelete, app.Handlers.DeleteProblemHandler)
 
-		admin.DELETE("/testcase/:id", app.Handlers.DeleteTestcaseHandler)
-		admin.DELETE("/problem/:uuid", app.Handlers.DeleteProblemHandler)
+		admin.GET("/users", canManageUsers, app.Handlers.GetUsersHandler)
+		admin.PUT("/user/:uuid/role", canManageUsers, app.Handlers.SetUserRoleHandler)
+		admin.DELETE("/user/:uuid/sessions", canManageUsers, app.Handlers.RevokeUserSessionsHandler)
 	}
 
 	app.Server = router
@@ -9,9 +9,13 @@ import (
 var CFG Config
 
 type Config struct {
-	Database  PostgreSQLConfig `mapstructure:"postgres" yaml:"postgres"`
-	SecretKey string           `mapstructure:"secret_key" yaml:"secret_key"`
-	Runtime   RuntimeConfig    `mapstructure:"runtime" yaml:"runtime"`
+	Database   PostgreSQLConfig `mapstructure:"postgres" yaml:"postgres"`
+	SecretKey  string           `mapstructure:"secret_key" yaml:"secret_key"`
+	Runtime    RuntimeConfig    `mapstructure:"runtime" yaml:"runtime"`
+	Plagiarism PlagiarismConfig `mapstructure:"plagiarism" yaml:"plagiarism"`
+	Storage    StorageConfig    `mapstructure:"storage" yaml:"storage"`
+	// DefaultLocale is the locale of base problem statements
+	DefaultLocale string `mapstructure:"default_locale" yaml:"default_locale"`
 }
 
 type PostgreSQLConfig struct {
@@ -27,6 +31,31 @@ type RuntimeConfig struct {
 	CPULimit        int   `mapstructure:"cpu_limit" yaml:"cpu_limit"`
 	ExecutionTimeMS int   `mapstructure:"execution_time_ms" yaml:"execution_time_ms"`
 	ProcessLimit    int64 `mapstructure:"process_limit" yaml:"process_limit"`
+	OutputLimitKB   int   `mapstructure:"output_limit_kb" yaml:"output_limit_kb"`
+}
+
+type PlagiarismConfig struct {
+	IntervalMinutes int     `mapstructure:"interval_minutes" yaml:"interval_minutes"`
+	Threshold       float64 `mapstructure:"threshold" yaml:"threshold"`
+	KGram           int     `mapstructure:"k_gram" yaml:"k_gram"`
+	Window          int     `mapstructure:"window" yaml:"window"`
+}
+
+type StorageConfig struct {
+	Backend  string   `mapstructure:"backend" yaml:"backend"` // "local" or "s3"
+	LocalDir string   `mapstructure:"local_dir" yaml:"local_dir"`
+	CacheDir string   `mapstructure:"cache_dir" yaml:"cache_dir"`
+	S3       S3Config `mapstructure:"s3" yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint" yaml:"endpoint"`
+	Region    string `mapstructure:"region" yaml:"region"`
+	Bucket    string `mapstructure:"bucket" yaml:"bucket"`
+	Prefix    string `mapstructure:"prefix" yaml:"prefix"`
+	AccessKey string `mapstructure:"access_key" yaml:"access_key"`
+	SecretKey string `mapstructure:"secret_key" yaml:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl" yaml:"use_ssl"`
 }
 
 func ConfigInit() {
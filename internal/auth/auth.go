@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"diplom/config"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -16,16 +15,17 @@ var (
 	ErrInvalidCredentials = errors.New("invalid username or password")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrSessionExpired     = errors.New("session expired")
-)
-
-var (
-	CookieExpirationTime = time.Now().Add(24 * time.Hour)
+	ErrTokenExpired       = errors.New("access token expired")
 )
 
 type SessionRepository interface {
-	AddSession(sess *Session) error
-	DeleteSessionByToken(token string) error
-	GetSessionByToken(token string) (*Session, error)
+	CreateSession(sess *Session, refreshHash string) error
+	GetSession(id string) (*Session, error)
+	GetUserSessions(userID string) ([]Session, error)
+	UseRefreshToken(hash string) (sessionID string, err error)
+	RotateRefreshToken(sessionID, hash string, client ClientInfo, expiresAt time.Time) error
+	DeleteSession(id string) error
+	DeleteUserSessions(userID string) (int, error)
 	VerifyUsernamePassword(username, password string) (userID, role string, match bool, err error)
 	AddUser(username, hashedPassword, role string) error
 	IsUserExists(username string) (bool, error)
@@ -33,6 +33,7 @@ type SessionRepository interface {
 
 type AuthService struct {
 	SessionRepo SessionRepository
+	AccessRepo  ProblemAccessRepository
 	Logger      *zap.Logger
 }
 
@@ -41,13 +42,17 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// LoginResponse is returned on login and on every refresh. ExpiresAt is the expiry of the access token.
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
 }
 
-func NewAuthService(repo SessionRepository, logger *zap.Logger) *AuthService {
+func NewAuthService(repo SessionRepository, accessRepo ProblemAccessRepository, logger *zap.Logger) *AuthService {
 	return &AuthService{
 		SessionRepo: repo,
+		AccessRepo:  accessRepo,
 		Logger:      logger.Named("auth"),
 	}
 }
@@ -55,33 +60,33 @@ func NewAuthService(repo SessionRepository, logger *zap.Logger) *AuthService {
 func (a *AuthService) IsAuthorized(tokenString string) (bool, *Claims, error) {
 	claims := &Claims{}
 
-	// Проверяем наличие сессии в базе данных
-	session, err := a.SessionRepo.GetSessionByToken(tokenString)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return false, nil, ErrInvalidToken
-		}
-		a.Logger.Error("failed to get session by token", zap.Error(err))
-		return false, nil, err
-	}
-
-	token, err := jwt.ParseWithClaims(session.Token, claims, func(token *jwt.Token) (interface{}, error) {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(config.CFG.SecretKey), nil
 	})
-
 	if err != nil {
-		if ve, ok := err.(*jwt.ValidationError); ok {
-			if ve.Errors&jwt.ValidationErrorExpired != 0 {
-				if err := a.SessionRepo.DeleteSessionByToken(token.Raw); err != nil {
-					a.Logger.Error("failed to delete session", zap.Error(err))
-					return false, nil, err
-				}
-				return false, nil, ErrSessionExpired
-			}
+		// Просроченный токен доступа обновляется через refresh token
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			return false, nil, ErrTokenExpired
 		}
 		return false, nil, ErrInvalidToken
 	}
 
+	// Проверяем, что сессия не отозвана и не истекла
+	session, err := a.SessionRepo.GetSession(claims.SessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return false, nil, ErrInvalidToken
+	} else if err != nil {
+		a.Logger.Error("failed to get session", zap.Error(err))
+		return false, nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return false, nil, ErrSessionExpired
+	}
+
+	// Роль берётся из базы, а не из токена: понижение прав действует сразу
+	claims.Role = session.Role
+	claims.Username = session.Username
+
 	return true, claims, nil
 }
 
@@ -97,25 +102,6 @@ func (a *AuthService) VerifyUserCredentials(username, password string) (userID s
 	return userID, role, nil
 }
 
-// CreateSession генерирует токен и добавляет сессию в базу данных.
-func (a *AuthService) CreateSession(username, userID, role string) (string, error) {
-	accessToken, err := GenerateToken(userID, role, username)
-	if err != nil {
-		return "", fmt.Errorf("failed to create access token, %w", err)
-	}
-	// Создание сессии
-	newSession := &Session{
-		Username: username,
-		Token:    accessToken,
-	}
-	// Сохранение сессии в базе данных
-	err = a.SessionRepo.AddSession(newSession)
-	if err != nil {
-		return "", fmt.Errorf("failed to write (rewrite) session, %w", err)
-	}
-	return accessToken, nil
-}
-
 // HashPassword hashes a plain-text password using bcrypt.
 func HashPassword(password string) (string, error) {
 	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -7,28 +7,27 @@ import (
 	"github.com/golang-jwt/jwt"
 )
 
+// AccessTokenTTL is kept short: the session is prolonged with the refresh token
+const AccessTokenTTL = 15 * time.Minute
+
 // Claims – кастомные данные, которые будут храниться в токене
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
 	jwt.StandardClaims
 }
 
-type Session struct {
-	Username string `json:"username"`
-	Token    string `json:"token"`
-}
-
-// GenerateToken генерирует JWT-токен для заданного пользователя и его роли
-func GenerateToken(userID string, role string, username string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateToken генерирует JWT-токен доступа для сессии пользователя
+func GenerateToken(userID, role, username, sessionID string, expiresAt time.Time) (string, error) {
 	claims := &Claims{
-		UserID:   userID,
-		Role:     role,
-		Username: username,
+		UserID:    userID,
+		Role:      role,
+		Username:  username,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			ExpiresAt: expiresAt.Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
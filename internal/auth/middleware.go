@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // AuthMiddleware проверяет наличие и валидность JWT-токена
@@ -22,25 +25,48 @@ func (a *AuthService) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
+		a.authenticate(c, parts[1])
+	}
+}
 
-		// Проверяем авторизацию и существование сессии в базе данных, не просрочена ли она
-		authorized, claims, err := a.IsAuthorized(tokenString)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authorized: " + err.Error()})
+// CookieAuthMiddleware дополнительно принимает токен из cookie сессии.
+// Используется только для GET-запросов файлов, которые браузер загружает сам
+// (например, <img> в условии), и не отправляет заголовок Authorization.
+func (a *AuthService) CookieAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			a.AuthMiddleware()(c)
 			return
-		} else if !authorized {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authorized"})
+		}
+
+		tokenString, err := c.Cookie("session")
+		if err != nil || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Отсутствует токен авторизации"})
 			return
 		}
 
-		// Сохраняем данные пользователя в контекст запроса
-		c.Set("userID", claims.UserID)
-		c.Set("role", claims.Role)
-		c.Set("username", claims.Username)
+		a.authenticate(c, tokenString)
+	}
+}
 
-		c.Next()
+func (a *AuthService) authenticate(c *gin.Context, tokenString string) {
+	// Проверяем авторизацию и существование сессии в базе данных, не просрочена ли она
+	authorized, claims, err := a.IsAuthorized(tokenString)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authorized: " + err.Error()})
+		return
+	} else if !authorized {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authorized"})
+		return
 	}
+
+	// Сохраняем данные пользователя в контекст запроса
+	c.Set("userID", claims.UserID)
+	c.Set("role", claims.Role)
+	c.Set("username", claims.Username)
+	c.Set("sessionID", claims.SessionID)
+
+	c.Next()
 }
 
 // RoleMiddleware проверяет, что роль пользователя соответствует одному из разрешённых
@@ -69,3 +95,59 @@ func (a *AuthService) RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Нет прав доступа"})
 	}
 }
+
+// PermissionMiddleware пропускает запрос, если роль даёт хотя бы одно из разрешений
+func (a *AuthService) PermissionMiddleware(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, permission := range permissions {
+			if HasPermission(role, permission) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Нет прав доступа"})
+	}
+}
+
+// ProblemAccessMiddleware проверяет право на действие с задачей с учётом владельца
+// и соавторов. Задача берётся из параметра :uuid, а для resource, отличного от
+// "problem", — по :id теста, подзадачи, подсказки и т.п.
+func (a *AuthService) ProblemAccessMiddleware(action, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		problemUUID := c.Param("uuid")
+		if resource != "problem" {
+			id, err := strconv.Atoi(c.Param("id"))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + resource + " ID format"})
+				return
+			}
+			problemUUID, err = a.AccessRepo.GetResourceProblem(resource, id)
+			if errors.Is(err, ErrResourceNotFound) {
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": resource + " not found"})
+				return
+			} else if err != nil {
+				a.Logger.Error("failed to resolve problem", zap.String("resource", resource), zap.Error(err))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check access"})
+				return
+			}
+		}
+
+		allowed, err := a.CanAccessProblem(c.GetString("role"), c.GetString("userID"), problemUUID, action)
+		if errors.Is(err, ErrResourceNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+			return
+		} else if err != nil {
+			a.Logger.Error("failed to check problem access", zap.String("uuid", problemUUID), zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check access"})
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Нет прав доступа к задаче"})
+			return
+		}
+
+		c.Next()
+	}
+}
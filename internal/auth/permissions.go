@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+)
+
+// Роли пользователей. Автор задач (например, ассистент курса) создаёт и
+// редактирует свои задачи и задачи, где он соавтор, но не чужие.
+const (
+	RoleUser   = "user"
+	RoleAuthor = "author"
+	RoleAdmin  = "admin"
+)
+
+// Permissions granted to roles. The "own" variants apply to problems the user
+// owns or co-authors, the "any" variants to every problem.
+const (
+	PermProblemsCreate    = "problems.create"
+	PermProblemsEditOwn   = "problems.edit_own"
+	PermProblemsEditAny   = "problems.edit_any"
+	PermProblemsDeleteOwn = "problems.delete_own"
+	PermProblemsDeleteAny = "problems.delete_any"
+	PermCollectionsManage = "collections.manage"
+	PermContestsManage    = "contests.manage"
+	PermUsersManage       = "users.manage"
+)
+
+// Actions on a particular problem checked by ProblemAccessMiddleware
+const (
+	// ActionEdit covers every change of the problem and its tests, hints, etc.
+	ActionEdit = "edit"
+	// ActionDelete removes the problem, only the owner may do it
+	ActionDelete = "delete"
+	// ActionManageAuthors changes co-authors, only the owner may do it
+	ActionManageAuthors = "manage_authors"
+)
+
+var (
+	ErrInvalidRole      = errors.New("role must be user, author or admin")
+	ErrAccessDenied     = errors.New("access denied")
+	ErrResourceNotFound = errors.New("resource not found")
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleAuthor: {
+		PermProblemsCreate,
+		PermProblemsEditOwn,
+		PermProblemsDeleteOwn,
+	},
+	RoleAdmin: {
+		PermProblemsCreate,
+		PermProblemsEditOwn,
+		PermProblemsEditAny,
+		PermProblemsDeleteOwn,
+		PermProblemsDeleteAny,
+		PermCollectionsManage,
+		PermContestsManage,
+		PermUsersManage,
+	},
+}
+
+// actionPermissions maps an action to the permission for any problem and
+// for problems the user has access to
+var actionPermissions = map[string]struct{ any, own string }{
+	ActionEdit:          {PermProblemsEditAny, PermProblemsEditOwn},
+	ActionDelete:        {PermProblemsDeleteAny, PermProblemsDeleteOwn},
+	ActionManageAuthors: {PermProblemsEditAny, PermProblemsEditOwn},
+}
+
+// ProblemAccess describes the relation of a user to a problem
+type ProblemAccess struct {
+	Owner    bool
+	CoAuthor bool
+}
+
+// ProblemAccessRepository resolves problem ownership
+type ProblemAccessRepository interface {
+	// GetProblemAccess returns ErrResourceNotFound if the problem doesn't exist
+	GetProblemAccess(problemUUID, userID string) (ProblemAccess, error)
+	// GetResourceProblem returns the problem a test case, subtask, hint, etc. belongs to
+	GetResourceProblem(resource string, id int) (string, error)
+}
+
+// ValidRole reports whether the role exists
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions returns the permissions of the role
+func Permissions(role string) []string {
+	return slices.Clone(rolePermissions[role])
+}
+
+// HasPermission reports whether the role grants the permission
+func HasPermission(role, permission string) bool {
+	return slices.Contains(rolePermissions[role], permission)
+}
+
+// CanAccessProblem checks whether the user may perform the action on the problem
+func (a *AuthService) CanAccessProblem(role, userID, problemUUID, action string) (bool, error) {
+	perms, ok := actionPermissions[action]
+	if !ok {
+		return false, nil
+	}
+	if HasPermission(role, perms.any) {
+		return true, nil
+	}
+	if !HasPermission(role, perms.own) {
+		return false, nil
+	}
+
+	access, err := a.AccessRepo.GetProblemAccess(problemUUID, userID)
+	if err != nil {
+		return false, err
+	}
+	if action == ActionEdit {
+		return access.Owner || access.CoAuthor, nil
+	}
+	return access.Owner, nil
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RefreshTokenTTL is how long a session lives without being used
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// maxUserAgentLength limits the stored user agent (in bytes)
+const maxUserAgentLength = 512
+
+var (
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrRefreshTokenReused = errors.New("refresh token has already been used, the session is revoked")
+)
+
+// Session is a login of the user on one device. Every refresh rotates the
+// refresh token; only hashes of the tokens are stored.
+type Session struct {
+	ID         string    `json:"id"`
+	UserUUID   string    `json:"-"`
+	Username   string    `json:"-"`
+	Role       string    `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// ClientInfo describes the device a session is used from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// hashToken returns the form of a refresh token kept in the database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (ci ClientInfo) normalized() ClientInfo {
+	if len(ci.UserAgent) > maxUserAgentLength {
+		ci.UserAgent = ci.UserAgent[:maxUserAgentLength]
+	}
+	return ci
+}
+
+// issueTokens signs an access token for the session and creates a new refresh token
+func (a *AuthService) issueTokens(session *Session) (LoginResponse, string, error) {
+	expiresAt := time.Now().Add(AccessTokenTTL)
+	accessToken, err := GenerateToken(session.UserUUID, session.Role, session.Username, session.ID, expiresAt)
+	if err != nil {
+		return LoginResponse{}, "", fmt.Errorf("failed to create access token, %w", err)
+	}
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return LoginResponse{}, "", fmt.Errorf("failed to create refresh token, %w", err)
+	}
+	return LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, hashToken(refreshToken), nil
+}
+
+// CreateSession starts a new session of the user. Other sessions of the user stay active.
+func (a *AuthService) CreateSession(username, userID, role string, client ClientInfo) (LoginResponse, error) {
+	client = client.normalized()
+	now := time.Now()
+	session := &Session{
+		ID:         uuid.NewString(),
+		UserUUID:   userID,
+		Username:   username,
+		Role:       role,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL),
+	}
+
+	tokens, refreshHash, err := a.issueTokens(session)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	if err := a.SessionRepo.CreateSession(session, refreshHash); err != nil {
+		return LoginResponse{}, fmt.Errorf("failed to write session, %w", err)
+	}
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new pair of tokens. A refresh token
+// may be used once: presenting a rotated token again means it has leaked,
+// so the whole session is revoked.
+func (a *AuthService) Refresh(refreshToken string, client ClientInfo) (LoginResponse, error) {
+	if refreshToken == "" {
+		return LoginResponse{}, ErrInvalidToken
+	}
+
+	sessionID, err := a.SessionRepo.UseRefreshToken(hashToken(refreshToken))
+	if errors.Is(err, ErrRefreshTokenReused) {
+		a.Logger.Warn("refresh token reuse detected, revoking session", zap.String("session", sessionID))
+		if err := a.SessionRepo.DeleteSession(sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return LoginResponse{}, err
+		}
+		return LoginResponse{}, ErrRefreshTokenReused
+	} else if err != nil {
+		return LoginResponse{}, err
+	}
+
+	session, err := a.SessionRepo.GetSession(sessionID)
+	if errors.Is(err, ErrSessionNotFound) {
+		return LoginResponse{}, ErrInvalidToken
+	} else if err != nil {
+		return LoginResponse{}, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return LoginResponse{}, ErrSessionExpired
+	}
+
+	tokens, refreshHash, err := a.issueTokens(session)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	if err := a.SessionRepo.RotateRefreshToken(session.ID, refreshHash, client.normalized(), time.Now().Add(RefreshTokenTTL)); err != nil {
+		return LoginResponse{}, fmt.Errorf("failed to rotate refresh token, %w", err)
+	}
+	return tokens, nil
+}
+
+// GetUserSessions returns active sessions of the user, the current one is marked
+func (a *AuthService) GetUserSessions(userID, currentSessionID string) ([]Session, error) {
+	sessions, err := a.SessionRepo.GetUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	active := sessions[:0]
+	now := time.Now()
+	for _, s := range sessions {
+		if now.After(s.ExpiresAt) {
+			continue
+		}
+		s.Current = s.ID == currentSessionID
+		active = append(active, s)
+	}
+	return active, nil
+}
+
+// RevokeSession ends one of the user's sessions
+func (a *AuthService) RevokeSession(userID, sessionID string) error {
+	session, err := a.SessionRepo.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserUUID != userID {
+		return ErrSessionNotFound
+	}
+	return a.SessionRepo.DeleteSession(sessionID)
+}
+
+// Logout ends the session the request was made with
+func (a *AuthService) Logout(sessionID string) error {
+	return a.SessionRepo.DeleteSession(sessionID)
+}
+
+// RevokeUserSessions ends all sessions of the user and returns how many there were.
+// Used for logging out everywhere and by admins, e.g. after a ban.
+func (a *AuthService) RevokeUserSessions(userID string) (int, error) {
+	return a.SessionRepo.DeleteUserSessions(userID)
+}
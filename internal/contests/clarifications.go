@@ -0,0 +1,211 @@
+package contests
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxClarificationLength limits questions, answers and announcements (in characters)
+const MaxClarificationLength = 4000
+
+var (
+	ErrClarificationNotFound = errors.New("clarification not found")
+	ErrClarificationTooLong  = errors.New("clarification text is too long")
+	ErrEmptyClarification    = errors.New("clarification text must not be empty")
+)
+
+// Clarification is a participant's question with the judges' answer, or an
+// announcement made by the judges (it has no author and no question). Public
+// clarifications are shown to every participant, private ones only to the author.
+type Clarification struct {
+	ID          int        `json:"id"`
+	ContestID   int        `json:"contest_id"`
+	ProblemUUID *string    `json:"problem_uuid,omitempty"`
+	Label       string     `json:"label,omitempty"`
+	UserUUID    *string    `json:"user_uuid,omitempty"`
+	Username    string     `json:"username,omitempty"`
+	Question    string     `json:"question,omitempty"`
+	Answer      *string    `json:"answer,omitempty"`
+	Public      bool       `json:"public"`
+	CreatedAt   time.Time  `json:"created_at"`
+	AnsweredAt  *time.Time `json:"answered_at,omitempty"`
+	AnsweredBy  *string    `json:"-"`
+}
+
+// AskRequest is a participant's question, optionally about one problem
+type AskRequest struct {
+	ProblemUUID *string `json:"problem_uuid"`
+	Question    string  `json:"question" binding:"required"`
+}
+
+// AnswerRequest answers a question privately or to everyone
+type AnswerRequest struct {
+	Answer string `json:"answer" binding:"required"`
+	Public bool   `json:"public"`
+}
+
+// AnnounceRequest is an announcement to all participants
+type AnnounceRequest struct {
+	ProblemUUID *string `json:"problem_uuid"`
+	Text        string  `json:"text" binding:"required"`
+}
+
+func (c Clarification) visibleTo(userID string, judge bool) bool {
+	return judge || c.Public || (c.UserUUID != nil && *c.UserUUID == userID)
+}
+
+// anonymized hides the author of someone else's question from participants
+func (c Clarification) anonymized(userID string) Clarification {
+	if c.UserUUID != nil && *c.UserUUID != userID {
+		c.UserUUID = nil
+		c.Username = ""
+	}
+	return c
+}
+
+func checkClarificationText(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return ErrEmptyClarification
+	}
+	if utf8.RuneCountInString(text) > MaxClarificationLength {
+		return ErrClarificationTooLong
+	}
+	return nil
+}
+
+// checkContestProblem makes sure the optional problem of a clarification belongs to the contest
+func (s *Service) checkContestProblem(id int, problemUUID *string) error {
+	if problemUUID == nil {
+		return nil
+	}
+	contestProblems, err := s.Repo.GetContestProblems(id)
+	if err != nil {
+		return fmt.Errorf("failed to get contest problems: %w", err)
+	}
+	for _, p := range contestProblems {
+		if p.UUID == *problemUUID {
+			return nil
+		}
+	}
+	return ErrProblemNotInContest
+}
+
+// GetClarifications returns clarifications of the contest visible to the user, newest first.
+// Judges see all of them.
+func (s *Service) GetClarifications(id int, userID string, judge bool) ([]Clarification, error) {
+	if _, err := s.Repo.GetContest(id, userID); err != nil {
+		return nil, err
+	}
+	return s.visibleClarifications(id, userID, judge)
+}
+
+func (s *Service) visibleClarifications(id int, userID string, judge bool) ([]Clarification, error) {
+	clarifications, err := s.Repo.GetClarifications(id, userID, judge)
+	if err != nil {
+		return nil, err
+	}
+	if !judge {
+		for i := range clarifications {
+			clarifications[i] = clarifications[i].anonymized(userID)
+		}
+	}
+	return clarifications, nil
+}
+
+// Ask saves a participant's question and notifies the judges
+func (s *Service) Ask(id int, userID string, req AskRequest) (Clarification, error) {
+	if err := checkClarificationText(req.Question); err != nil {
+		return Clarification{}, err
+	}
+	contest, err := s.Repo.GetContest(id, userID)
+	if err != nil {
+		return Clarification{}, err
+	}
+	if !contest.Registered && contest.VirtualStart == nil {
+		return Clarification{}, ErrNotRegistered
+	}
+	if contest.StatusAt(time.Now()) == StatusUpcoming {
+		return Clarification{}, ErrContestNotStarted
+	}
+	if err := s.checkContestProblem(id, req.ProblemUUID); err != nil {
+		return Clarification{}, err
+	}
+
+	clarificationID, err := s.Repo.SaveClarification(Clarification{
+		ContestID:   id,
+		ProblemUUID: req.ProblemUUID,
+		UserUUID:    &userID,
+		Question:    req.Question,
+	})
+	if err != nil {
+		return Clarification{}, err
+	}
+	return s.notify(clarificationID, EventQuestion)
+}
+
+// Answer answers a question. A public answer is broadcast to all participants,
+// a private one is sent to the author only.
+func (s *Service) Answer(clarificationID int, judgeUUID string, req AnswerRequest) (Clarification, error) {
+	if err := checkClarificationText(req.Answer); err != nil {
+		return Clarification{}, err
+	}
+	if err := s.Repo.AnswerClarification(clarificationID, judgeUUID, req.Answer, req.Public); err != nil {
+		return Clarification{}, err
+	}
+	return s.notify(clarificationID, EventAnswer)
+}
+
+// Announce publishes an announcement to all participants of the contest
+func (s *Service) Announce(id int, judgeUUID string, req AnnounceRequest) (Clarification, error) {
+	if err := checkClarificationText(req.Text); err != nil {
+		return Clarification{}, err
+	}
+	if _, err := s.Repo.GetContest(id, ""); err != nil {
+		return Clarification{}, err
+	}
+	if err := s.checkContestProblem(id, req.ProblemUUID); err != nil {
+		return Clarification{}, err
+	}
+
+	now := time.Now()
+	clarificationID, err := s.Repo.SaveClarification(Clarification{
+		ContestID:   id,
+		ProblemUUID: req.ProblemUUID,
+		Answer:      &req.Text,
+		Public:      true,
+		AnsweredAt:  &now,
+		AnsweredBy:  &judgeUUID,
+	})
+	if err != nil {
+		return Clarification{}, err
+	}
+	return s.notify(clarificationID, EventAnnouncement)
+}
+
+// notify reloads the clarification and pushes it to the subscribers allowed to see it
+func (s *Service) notify(clarificationID int, eventType string) (Clarification, error) {
+	clarification, err := s.Repo.GetClarification(clarificationID)
+	if err != nil {
+		return Clarification{}, err
+	}
+	s.events.publish(clarification.ContestID, Event{Type: eventType, Clarification: clarification})
+	return clarification, nil
+}
+
+// Subscribe returns a stream of clarification events of the contest for a
+// participant or a judge. The returned function must be called to unsubscribe.
+func (s *Service) Subscribe(id int, userID string, judge bool) (<-chan Event, func(), error) {
+	contest, err := s.Repo.GetContest(id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !judge && !contest.Registered && contest.VirtualStart == nil {
+		return nil, nil, ErrNotRegistered
+	}
+
+	sub := s.events.subscribe(id, userID, judge)
+	return sub.events, func() { s.events.unsubscribe(sub) }, nil
+}
@@ -0,0 +1,322 @@
+package contests
+
+import (
+	"context"
+	"diplom/internal/problems"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Contest statuses computed from the time window
+const (
+	StatusUpcoming = "upcoming"
+	StatusRunning  = "running"
+	StatusFinished = "finished"
+)
+
+var (
+	ErrContestNotFound        = errors.New("contest not found")
+	ErrInvalidContestTime     = errors.New("end_time must be after start_time")
+	ErrInvalidFreezeTime      = errors.New("freeze_time must be within the contest")
+	ErrDuplicateProblem       = errors.New("contest lists the same problem twice")
+	ErrContestNotStarted      = errors.New("contest has not started yet")
+	ErrContestNotRunning      = errors.New("contest is not running")
+	ErrContestFinished        = errors.New("contest is already finished")
+	ErrNotRegistered          = errors.New("user is not registered for the contest")
+	ErrProblemNotInContest    = errors.New("problem is not part of the contest")
+	ErrAlreadyRegistered      = errors.New("user is already registered for the contest")
+	ErrTooManyContestProblems = errors.New("contest may have at most 26 problems")
+	ErrContestNotFrozen       = errors.New("contest has no scoreboard freeze")
+	ErrContestNotFinished     = errors.New("contest is not finished yet")
+	ErrVirtualRunExists       = errors.New("virtual run of the contest is already started")
+	ErrNoVirtualRun           = errors.New("user has no virtual run of the contest")
+	ErrTeamModeLocked         = errors.New("team mode cannot be changed once participants are registered")
+)
+
+// Contest is a set of problems solved within a time window. Registered,
+// Participants and Status are computed for the requesting user. Results of
+// submissions made after FreezeTime are hidden from the public standings until
+// they are revealed by the resolver. VirtualStart is set if the user takes the
+// finished contest as a virtual participant. In team contests teams take part
+// instead of users, TeamID is the team the user participates with.
+type Contest struct {
+	ID           int              `json:"id"`
+	Title        string           `json:"title"`
+	Description  string           `json:"description"`
+	StartTime    time.Time        `json:"start_time"`
+	EndTime      time.Time        `json:"end_time"`
+	FreezeTime   *time.Time       `json:"freeze_time,omitempty"`
+	TeamMode     bool             `json:"team_mode"`
+	Status       string           `json:"status"`
+	Participants int              `json:"participants"`
+	Registered   bool             `json:"registered"`
+	VirtualStart *time.Time       `json:"virtual_start,omitempty"`
+	TeamID       *int             `json:"team_id,omitempty"`
+	Problems     []ContestProblem `json:"problems,omitempty"`
+	// Clarifications visible to the user: announcements, public answers and own questions
+	Clarifications []Clarification `json:"clarifications,omitempty"`
+}
+
+// ContestProblem is a problem of a contest labelled A, B, C... by position
+type ContestProblem struct {
+	Label    string `json:"label"`
+	UUID     string `json:"uuid"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+// ContestRequest creates or replaces a contest. Problems are listed in order.
+type ContestRequest struct {
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	StartTime   time.Time  `json:"start_time" binding:"required"`
+	EndTime     time.Time  `json:"end_time" binding:"required"`
+	FreezeTime  *time.Time `json:"freeze_time"`
+	TeamMode    bool       `json:"team_mode"`
+	Problems    []string   `json:"problems"`
+}
+
+// Participant is a user or, in team contests, a team registered for a contest.
+// Virtual participants have their own start time after the end of the contest.
+type Participant struct {
+	UserUUID     string     `json:"user_uuid,omitempty"`
+	Username     string     `json:"username,omitempty"`
+	TeamID       *int       `json:"team_id,omitempty"`
+	TeamName     string     `json:"team_name,omitempty"`
+	RegisteredAt time.Time  `json:"registered_at"`
+	VirtualStart *time.Time `json:"virtual_start,omitempty"`
+}
+
+// Submission is a judged contest submission used to build the standings.
+// Hidden submissions were made during the freeze and show up as pending.
+type Submission struct {
+	ID          int
+	UserUUID    string
+	TeamID      *int
+	ProblemUUID string
+	Accepted    bool
+	CreatedAt   time.Time
+	Hidden      bool
+}
+
+// Repository defines the data access interface for contests
+type Repository interface {
+	GetContests(userID string) ([]Contest, error)
+	GetContest(id int, userID string) (Contest, error)
+	GetContestProblems(id int) ([]ContestProblem, error)
+	CreateContest(authorUUID string, req ContestRequest) (int, error)
+	UpdateContest(id int, req ContestRequest) error
+	DeleteContest(id int) error
+	RegisterParticipant(id int, userID string, teamID *int) error
+	GetParticipants(id int) ([]Participant, error)
+	StartVirtualRun(id int, userID string, teamID *int, start time.Time) error
+	IsTeamMember(teamID int, userID string) (bool, error)
+	IsTeamMemberRegistered(id, teamID int) (bool, error)
+	GetContestSubmissions(id int) ([]Submission, error)
+	GetRevealedSubmissions(id int) (map[int]bool, error)
+	RevealSubmissions(id int, solutionIDs []int) error
+	GetClarifications(id int, userID string, all bool) ([]Clarification, error)
+	GetClarification(clarificationID int) (Clarification, error)
+	SaveClarification(c Clarification) (int, error)
+	AnswerClarification(clarificationID int, judgeUUID, answer string, public bool) error
+}
+
+// Service manages contests and judges submissions made within them
+type Service struct {
+	Repo     Repository
+	Problems *problems.ProblemService
+	Logger   *zap.Logger
+
+	events *broker
+}
+
+// NewService creates a contest service on top of the problem service
+func NewService(repo Repository, problemService *problems.ProblemService, logger *zap.Logger) *Service {
+	return &Service{
+		Repo:     repo,
+		Problems: problemService,
+		Logger:   logger.Named("contests"),
+		events:   newBroker(),
+	}
+}
+
+// ProblemLabel returns the label of the problem at the given position: A, B, ... Z
+func ProblemLabel(position int) string {
+	return string(rune('A' + position))
+}
+
+// StatusAt returns the status of the contest at the given moment
+func (c *Contest) StatusAt(now time.Time) string {
+	switch {
+	case now.Before(c.StartTime):
+		return StatusUpcoming
+	case now.Before(c.EndTime):
+		return StatusRunning
+	default:
+		return StatusFinished
+	}
+}
+
+// GetContests returns all contests, newest first
+func (s *Service) GetContests(userID string) ([]Contest, error) {
+	contests, err := s.Repo.GetContests(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range contests {
+		contests[i].Status = contests[i].StatusAt(now)
+	}
+	return contests, nil
+}
+
+// GetContest returns a contest with the clarifications visible to the user.
+// Its problems are hidden until the start from everyone but contest managers,
+// who also see all clarifications.
+func (s *Service) GetContest(id int, userID string, manager bool) (Contest, error) {
+	contest, err := s.Repo.GetContest(id, userID)
+	if err != nil {
+		return Contest{}, err
+	}
+	contest.Status = contest.StatusAt(time.Now())
+
+	contest.Clarifications, err = s.visibleClarifications(id, userID, manager)
+	if err != nil {
+		return Contest{}, fmt.Errorf("failed to get clarifications: %w", err)
+	}
+	if contest.Status == StatusUpcoming && !manager {
+		return contest, nil
+	}
+
+	contest.Problems, err = s.Repo.GetContestProblems(id)
+	if err != nil {
+		return Contest{}, fmt.Errorf("failed to get contest problems: %w", err)
+	}
+	return contest, nil
+}
+
+// CreateContest validates and saves a new contest
+func (s *Service) CreateContest(authorUUID string, req ContestRequest) (int, error) {
+	if err := s.validateContest(req); err != nil {
+		return 0, err
+	}
+	return s.Repo.CreateContest(authorUUID, req)
+}
+
+// UpdateContest validates and replaces a contest
+func (s *Service) UpdateContest(id int, req ContestRequest) error {
+	if err := s.validateContest(req); err != nil {
+		return err
+	}
+	contest, err := s.Repo.GetContest(id, "")
+	if err != nil {
+		return err
+	}
+	if contest.TeamMode != req.TeamMode && contest.Participants > 0 {
+		return ErrTeamModeLocked
+	}
+	return s.Repo.UpdateContest(id, req)
+}
+
+func (s *Service) validateContest(req ContestRequest) error {
+	if !req.EndTime.After(req.StartTime) {
+		return ErrInvalidContestTime
+	}
+	if req.FreezeTime != nil && (req.FreezeTime.Before(req.StartTime) || !req.FreezeTime.Before(req.EndTime)) {
+		return ErrInvalidFreezeTime
+	}
+	if len(req.Problems) > 26 {
+		return ErrTooManyContestProblems
+	}
+	seen := make(map[string]struct{}, len(req.Problems))
+	for _, problemUUID := range req.Problems {
+		if _, ok := seen[problemUUID]; ok {
+			return ErrDuplicateProblem
+		}
+		seen[problemUUID] = struct{}{}
+		if _, err := s.Problems.ProblemRepo.GetProblemByUUID(problemUUID, ""); err != nil {
+			return fmt.Errorf("problem %s: %w", problemUUID, err)
+		}
+	}
+	return nil
+}
+
+// Register adds the user, or their team in a team contest, to the participants.
+// Registration stays open until the end.
+func (s *Service) Register(id int, userID string, req RegisterRequest) error {
+	contest, err := s.Repo.GetContest(id, userID)
+	if err != nil {
+		return err
+	}
+	if contest.StatusAt(time.Now()) == StatusFinished {
+		return ErrContestFinished
+	}
+	if contest.Registered {
+		return ErrAlreadyRegistered
+	}
+	if err := s.checkTeam(&contest, userID, req.TeamID); err != nil {
+		return err
+	}
+	return s.Repo.RegisterParticipant(id, userID, req.TeamID)
+}
+
+// GetProblem returns a contest problem to a participant once the contest has
+// started. Contest problems may be unpublished, so the usual visibility rules
+// of the problem set do not apply.
+func (s *Service) GetProblem(id int, userID, problemUUID string) (*problems.Problem, ContestProblem, error) {
+	contest, contestProblem, err := s.checkParticipant(id, userID, problemUUID)
+	if err != nil {
+		return nil, ContestProblem{}, err
+	}
+	if contest.StatusAt(time.Now()) == StatusUpcoming {
+		return nil, ContestProblem{}, ErrContestNotStarted
+	}
+
+	problem, err := s.Problems.ProblemRepo.GetProblemByUUID(problemUUID, userID)
+	if err != nil {
+		return nil, ContestProblem{}, err
+	}
+	return problem, contestProblem, nil
+}
+
+// Submit judges a solution of a participant while the contest or their virtual
+// run is going. The submission is saved with the contest ID and counts towards the standings.
+func (s *Service) Submit(ctx context.Context, id int, userID string, req problems.SolutionRequest) (*problems.SubmitResult, error) {
+	contest, _, err := s.checkParticipant(id, userID, req.ProblemUUID)
+	if err != nil {
+		return nil, err
+	}
+	if contest.ParticipantStatusAt(time.Now()) != StatusRunning {
+		return nil, ErrContestNotRunning
+	}
+
+	req.ContestID = &contest.ID
+	req.TeamID = contest.TeamID
+	return s.Problems.ProcessSolution(ctx, req, userID)
+}
+
+// checkParticipant makes sure the user is registered or runs the contest
+// virtually and the problem belongs to the contest
+func (s *Service) checkParticipant(id int, userID, problemUUID string) (Contest, ContestProblem, error) {
+	contest, err := s.Repo.GetContest(id, userID)
+	if err != nil {
+		return Contest{}, ContestProblem{}, err
+	}
+	if !contest.Registered && contest.VirtualStart == nil {
+		return Contest{}, ContestProblem{}, ErrNotRegistered
+	}
+
+	contestProblems, err := s.Repo.GetContestProblems(id)
+	if err != nil {
+		return Contest{}, ContestProblem{}, fmt.Errorf("failed to get contest problems: %w", err)
+	}
+	for _, p := range contestProblems {
+		if p.UUID == problemUUID {
+			return contest, p, nil
+		}
+	}
+	return Contest{}, ContestProblem{}, ErrProblemNotInContest
+}
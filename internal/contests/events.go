@@ -0,0 +1,76 @@
+package contests
+
+import "sync"
+
+// Types of events pushed to contest subscribers
+const (
+	EventQuestion     = "question"
+	EventAnswer       = "answer"
+	EventAnnouncement = "announcement"
+)
+
+// eventBuffer is how many events a slow subscriber may lag behind before new ones are dropped
+const eventBuffer = 16
+
+// Event is a clarification update pushed over SSE
+type Event struct {
+	Type          string
+	Clarification Clarification
+}
+
+type subscriber struct {
+	contestID int
+	userID    string
+	judge     bool
+	events    chan Event
+}
+
+// broker fans clarification events out to the subscribers of a contest.
+// Subscribers only get events they are allowed to see.
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subscribers: make(map[*subscriber]struct{})}
+}
+
+func (b *broker) subscribe(contestID int, userID string, judge bool) *subscriber {
+	sub := &subscriber{
+		contestID: contestID,
+		userID:    userID,
+		judge:     judge,
+		events:    make(chan Event, eventBuffer),
+	}
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+func (b *broker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	delete(b.subscribers, sub)
+	b.mu.Unlock()
+}
+
+func (b *broker) publish(contestID int, event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if sub.contestID != contestID || !event.Clarification.visibleTo(sub.userID, sub.judge) {
+			continue
+		}
+		e := event
+		if !sub.judge {
+			e.Clarification = e.Clarification.anonymized(sub.userID)
+		}
+		select {
+		case sub.events <- e:
+		default:
+			// Клиент не успевает читать: событие он увидит в списке при переподключении
+		}
+	}
+}
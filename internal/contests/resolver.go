@@ -0,0 +1,90 @@
+package contests
+
+import "time"
+
+// RevealedSubmission is a frozen result disclosed by the resolver
+type RevealedSubmission struct {
+	UserUUID string `json:"user_uuid,omitempty"`
+	Username string `json:"username,omitempty"`
+	TeamID   *int   `json:"team_id,omitempty"`
+	TeamName string `json:"team_name,omitempty"`
+	Label    string `json:"label"`
+	Accepted bool   `json:"accepted"`
+}
+
+// UnfreezeResult contains the submissions revealed by a resolver step and the
+// public standings after them. Standings.Frozen turns false after the last step.
+type UnfreezeResult struct {
+	Revealed  []RevealedSubmission `json:"revealed"`
+	Standings Standings            `json:"standings"`
+}
+
+// Unfreeze reveals the next hidden submission in resolver order, or all of them
+// at once. Resolver order is the one of the ICPC award ceremony: the lowest
+// ranked participant with pending results goes first, their problems left to
+// right, and the board is re-ranked after every reveal.
+func (s *Service) Unfreeze(id int, all bool) (UnfreezeResult, error) {
+	data, err := s.loadStandings(id, "", false)
+	if err != nil {
+		return UnfreezeResult{}, err
+	}
+	if data.contest.FreezeTime == nil {
+		return UnfreezeResult{}, ErrContestNotFrozen
+	}
+	if data.contest.StatusAt(time.Now()) != StatusFinished {
+		return UnfreezeResult{}, ErrContestNotFinished
+	}
+	if err := s.hideFrozen(&data); err != nil {
+		return UnfreezeResult{}, err
+	}
+
+	result := UnfreezeResult{Revealed: []RevealedSubmission{}}
+	var solutionIDs []int
+	for {
+		rows := BuildStandings(data.contest.StartTime, data.contest.Duration(), data.problems, data.participants, data.submissions)
+		i, revealed, ok := nextReveal(rows, data.problems, data.submissions)
+		if !ok {
+			break
+		}
+
+		data.submissions[i].Hidden = false
+		solutionIDs = append(solutionIDs, data.submissions[i].ID)
+		result.Revealed = append(result.Revealed, revealed)
+		if !all {
+			break
+		}
+	}
+
+	if len(solutionIDs) > 0 {
+		if err := s.Repo.RevealSubmissions(id, solutionIDs); err != nil {
+			return UnfreezeResult{}, err
+		}
+	}
+	result.Standings = data.build(data.contest.Duration())
+	return result, nil
+}
+
+// nextReveal picks the earliest hidden submission of the first pending problem
+// of the lowest ranked participant with pending results
+func nextReveal(rows []StandingsRow, contestProblems []ContestProblem, submissions []Submission) (int, RevealedSubmission, bool) {
+	for r := len(rows) - 1; r >= 0; r-- {
+		for j, result := range rows[r].Problems {
+			if result.Pending == 0 {
+				continue
+			}
+			for i, sub := range submissions {
+				if sub.Hidden && sub.key() == rows[r].key() && sub.ProblemUUID == contestProblems[j].UUID {
+					return i, RevealedSubmission{
+						UserUUID: rows[r].UserUUID,
+						Username: rows[r].Username,
+						TeamID:   rows[r].TeamID,
+						TeamName: rows[r].TeamName,
+						Label:    result.Label,
+						Accepted: sub.Accepted,
+					}, true
+				}
+			}
+		}
+	}
+	return 0, RevealedSubmission{}, false
+}
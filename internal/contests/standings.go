@@ -0,0 +1,221 @@
+package contests
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// PenaltyPerWrongAttempt is added for every rejected attempt before the first accepted one
+const PenaltyPerWrongAttempt = 20 * time.Minute
+
+// Standings is the ICPC-style scoreboard of a contest. Frozen is set while
+// some results of the freeze period are still hidden.
+type Standings struct {
+	ContestID  int              `json:"contest_id"`
+	Frozen     bool             `json:"frozen"`
+	FreezeTime *time.Time       `json:"freeze_time,omitempty"`
+	Problems   []ContestProblem `json:"problems"`
+	Rows       []StandingsRow   `json:"rows"`
+}
+
+// StandingsRow is the result of one participant, a user or a team. Penalty is in minutes.
+type StandingsRow struct {
+	Rank     int             `json:"rank"`
+	UserUUID string          `json:"user_uuid,omitempty"`
+	Username string          `json:"username,omitempty"`
+	TeamID   *int            `json:"team_id,omitempty"`
+	TeamName string          `json:"team_name,omitempty"`
+	Virtual  bool            `json:"virtual,omitempty"`
+	Solved   int             `json:"solved"`
+	Penalty  int             `json:"penalty"`
+	Problems []ProblemResult `json:"problems"`
+}
+
+// ProblemResult is the participant's result on one problem. Attempts counts
+// rejected submissions before the first accepted one (or all of them if unsolved),
+// SolvedAt is the number of minutes from the start to the accepted submission.
+// Pending counts hidden submissions made during the freeze.
+type ProblemResult struct {
+	Label    string `json:"label"`
+	Solved   bool   `json:"solved"`
+	Attempts int    `json:"attempts"`
+	Pending  int    `json:"pending,omitempty"`
+	SolvedAt *int   `json:"solved_at,omitempty"`
+}
+
+// standingsData is everything the scoreboard of a contest is built from.
+// Only official participants are included unless withVirtual is set.
+type standingsData struct {
+	contest      Contest
+	problems     []ContestProblem
+	participants []Participant
+	submissions  []Submission
+}
+
+func (s *Service) loadStandings(id int, userID string, withVirtual bool) (standingsData, error) {
+	contest, err := s.Repo.GetContest(id, userID)
+	if err != nil {
+		return standingsData{}, err
+	}
+	contestProblems, err := s.Repo.GetContestProblems(id)
+	if err != nil {
+		return standingsData{}, err
+	}
+	participants, err := s.Repo.GetParticipants(id)
+	if err != nil {
+		return standingsData{}, err
+	}
+	if !withVirtual {
+		participants = slices.DeleteFunc(participants, func(p Participant) bool { return p.VirtualStart != nil })
+	}
+	submissions, err := s.Repo.GetContestSubmissions(id)
+	if err != nil {
+		return standingsData{}, err
+	}
+	return standingsData{contest, contestProblems, participants, submissions}, nil
+}
+
+// hideFrozen marks submissions made after the freeze that the resolver has not
+// revealed yet. Virtual runs start after the end and are never hidden.
+func (s *Service) hideFrozen(data *standingsData) error {
+	freeze := data.contest.FreezeTime
+	if freeze == nil || time.Now().Before(*freeze) {
+		return nil
+	}
+	revealed, err := s.Repo.GetRevealedSubmissions(data.contest.ID)
+	if err != nil {
+		return err
+	}
+	for i := range data.submissions {
+		sub := &data.submissions[i]
+		sub.Hidden = !sub.CreatedAt.Before(*freeze) && sub.CreatedAt.Before(data.contest.EndTime) && !revealed[sub.ID]
+	}
+	return nil
+}
+
+// build ranks the submissions made within the first elapsed time of every participant's run
+func (d *standingsData) build(elapsed time.Duration) Standings {
+	rows := BuildStandings(d.contest.StartTime, elapsed, d.problems, d.participants, d.submissions)
+	return Standings{
+		ContestID:  d.contest.ID,
+		Frozen:     hasPending(rows),
+		FreezeTime: d.contest.FreezeTime,
+		Problems:   d.problems,
+		Rows:       rows,
+	}
+}
+
+// GetStandings builds the scoreboard from the judged submissions of the contest.
+// Unless live is set, results of the freeze period stay hidden until revealed.
+func (s *Service) GetStandings(id int, live bool) (Standings, error) {
+	data, err := s.loadStandings(id, "", false)
+	if err != nil {
+		return Standings{}, err
+	}
+	if !live {
+		if err := s.hideFrozen(&data); err != nil {
+			return Standings{}, err
+		}
+	}
+	return data.build(data.contest.Duration()), nil
+}
+
+// BuildStandings ranks participants by the number of solved problems and then
+// by penalty time: minutes from the start to each accepted submission plus
+// 20 minutes for every rejected attempt before it. Submissions after the first
+// accepted one are ignored, hidden ones are only counted as pending.
+// Participants with equal results share the rank. Submissions must be ordered by time.
+//
+// Time is counted from the contest start, or from their own start for virtual
+// participants; only submissions within the first elapsed time of the run count.
+// In team contests submissions of all members count for their team.
+func BuildStandings(start time.Time, elapsed time.Duration, contestProblems []ContestProblem, participants []Participant, submissions []Submission) []StandingsRow {
+	column := make(map[string]int, len(contestProblems))
+	for i, p := range contestProblems {
+		column[p.UUID] = i
+	}
+
+	rows := make([]StandingsRow, len(participants))
+	byParticipant := make(map[string]*StandingsRow, len(participants))
+	starts := make(map[string]time.Time, len(participants))
+	for i, p := range participants {
+		rows[i] = StandingsRow{
+			UserUUID: p.UserUUID,
+			Username: p.Username,
+			TeamID:   p.TeamID,
+			TeamName: p.TeamName,
+			Virtual:  p.VirtualStart != nil,
+			Problems: make([]ProblemResult, len(contestProblems)),
+		}
+		starts[p.key()] = start
+		if p.VirtualStart != nil {
+			starts[p.key()] = *p.VirtualStart
+		}
+		for j, cp := range contestProblems {
+			rows[i].Problems[j].Label = cp.Label
+		}
+		byParticipant[p.key()] = &rows[i]
+	}
+
+	for _, sub := range submissions {
+		row, ok := byParticipant[sub.key()]
+		if !ok {
+			continue
+		}
+		j, ok := column[sub.ProblemUUID]
+		if !ok {
+			continue
+		}
+		offset := sub.CreatedAt.Sub(starts[sub.key()])
+		if offset < 0 || offset >= elapsed {
+			continue
+		}
+		result := &row.Problems[j]
+		switch {
+		case result.Solved:
+			continue
+		case sub.Hidden:
+			result.Pending++
+			continue
+		case !sub.Accepted:
+			result.Attempts++
+			continue
+		}
+
+		minutes := int(offset / time.Minute)
+		result.Solved = true
+		result.SolvedAt = &minutes
+		row.Solved++
+		row.Penalty += minutes + result.Attempts*int(PenaltyPerWrongAttempt/time.Minute)
+	}
+
+	sort.SliceStable(rows, func(a, b int) bool {
+		if rows[a].Solved != rows[b].Solved {
+			return rows[a].Solved > rows[b].Solved
+		}
+		if rows[a].Penalty != rows[b].Penalty {
+			return rows[a].Penalty < rows[b].Penalty
+		}
+		return rows[a].name() < rows[b].name()
+	})
+	for i := range rows {
+		if i > 0 && rows[i].Solved == rows[i-1].Solved && rows[i].Penalty == rows[i-1].Penalty {
+			rows[i].Rank = rows[i-1].Rank
+		} else {
+			rows[i].Rank = i + 1
+		}
+	}
+	return rows
+}
+
+func hasPending(rows []StandingsRow) bool {
+	for _, row := range rows {
+		for _, result := range row.Problems {
+			if result.Pending > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
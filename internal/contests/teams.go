@@ -0,0 +1,72 @@
+package contests
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var (
+	ErrTeamRequired            = errors.New("team_id is required in a team contest")
+	ErrTeamNotAllowed          = errors.New("contest is individual, team_id is not allowed")
+	ErrNotTeamMember           = errors.New("user is not a member of the team")
+	ErrMemberAlreadyRegistered = errors.New("a member of the team already takes part in the contest")
+)
+
+// RegisterRequest selects the team to take part with in a team contest
+type RegisterRequest struct {
+	TeamID *int `json:"team_id"`
+}
+
+// checkTeam validates the team of a registration: it is required in team
+// contests, the user must be its member and no member may take part twice
+func (s *Service) checkTeam(contest *Contest, userID string, teamID *int) error {
+	if !contest.TeamMode {
+		if teamID != nil {
+			return ErrTeamNotAllowed
+		}
+		return nil
+	}
+	if teamID == nil {
+		return ErrTeamRequired
+	}
+
+	member, err := s.Repo.IsTeamMember(*teamID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check team membership: %w", err)
+	}
+	if !member {
+		return ErrNotTeamMember
+	}
+	registered, err := s.Repo.IsTeamMemberRegistered(contest.ID, *teamID)
+	if err != nil {
+		return fmt.Errorf("failed to check team registration: %w", err)
+	}
+	if registered {
+		return ErrMemberAlreadyRegistered
+	}
+	return nil
+}
+
+// participantKey identifies the participant a submission is attributed to:
+// the team in team contests, the user otherwise
+func participantKey(userUUID string, teamID *int) string {
+	if teamID != nil {
+		return "team:" + strconv.Itoa(*teamID)
+	}
+	return "user:" + userUUID
+}
+
+func (p Participant) key() string { return participantKey(p.UserUUID, p.TeamID) }
+
+func (s Submission) key() string { return participantKey(s.UserUUID, s.TeamID) }
+
+func (r StandingsRow) key() string { return participantKey(r.UserUUID, r.TeamID) }
+
+// name returns the name shown in the standings
+func (r StandingsRow) name() string {
+	if r.TeamID != nil {
+		return r.TeamName
+	}
+	return r.Username
+}
@@ -0,0 +1,64 @@
+package contests
+
+import "time"
+
+// Duration returns the length of the contest
+func (c *Contest) Duration() time.Duration {
+	return c.EndTime.Sub(c.StartTime)
+}
+
+// ParticipantStatusAt returns the status of the contest for the requesting user:
+// a virtual run lasts as long as the contest, counted from the user's own start
+func (c *Contest) ParticipantStatusAt(now time.Time) string {
+	if c.VirtualStart == nil {
+		return c.StatusAt(now)
+	}
+	if now.Before(c.VirtualStart.Add(c.Duration())) {
+		return StatusRunning
+	}
+	return StatusFinished
+}
+
+// StartVirtual starts a virtual run of a finished contest for the user or their
+// team. Official participants already know the problems and cannot take the contest again.
+func (s *Service) StartVirtual(id int, userID string, req RegisterRequest) (Contest, error) {
+	contest, err := s.Repo.GetContest(id, userID)
+	if err != nil {
+		return Contest{}, err
+	}
+	switch {
+	case contest.StatusAt(time.Now()) != StatusFinished:
+		return Contest{}, ErrContestNotFinished
+	case contest.Registered:
+		return Contest{}, ErrAlreadyRegistered
+	case contest.VirtualStart != nil:
+		return Contest{}, ErrVirtualRunExists
+	}
+	if err := s.checkTeam(&contest, userID, req.TeamID); err != nil {
+		return Contest{}, err
+	}
+
+	if err := s.Repo.StartVirtualRun(id, userID, req.TeamID, time.Now()); err != nil {
+		return Contest{}, err
+	}
+	return s.GetContest(id, userID, false)
+}
+
+// GetVirtualStandings ranks the user's virtual run together with official and
+// other virtual participants as they were at the same elapsed time. Results
+// still frozen on the official board stay hidden here as well.
+func (s *Service) GetVirtualStandings(id int, userID string) (Standings, error) {
+	data, err := s.loadStandings(id, userID, true)
+	if err != nil {
+		return Standings{}, err
+	}
+	if data.contest.VirtualStart == nil {
+		return Standings{}, ErrNoVirtualRun
+	}
+	if err := s.hideFrozen(&data); err != nil {
+		return Standings{}, err
+	}
+
+	elapsed := min(time.Since(*data.contest.VirtualStart), data.contest.Duration())
+	return data.build(elapsed), nil
+}
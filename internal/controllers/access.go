@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"diplom/internal/auth"
+	"diplom/internal/problems"
+	"diplom/internal/user"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetCoAuthorsHandler returns the owner and co-authors of a problem
+func (h *Handlers) GetCoAuthorsHandler(c *gin.Context) {
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(c.Param("uuid"), "")
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		return
+	}
+
+	coAuthors, err := h.ProblemService.ProblemRepo.GetCoAuthors(problem.UUID)
+	if err != nil {
+		h.Logger.Error("failed to get co-authors", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get co-authors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owner_uuid": problem.OwnerUUID, "coauthors": coAuthors})
+}
+
+// AddCoAuthorHandler lets another user edit the problem
+func (h *Handlers) AddCoAuthorHandler(c *gin.Context) {
+	var req problems.AddCoAuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	coAuthor, err := h.ProblemService.AddCoAuthor(c.Param("uuid"), req.Username)
+	switch {
+	case errors.Is(err, problems.ErrProblemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+	case errors.Is(err, problems.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	case errors.Is(err, problems.ErrCoAuthorIsOwner):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		h.Logger.Error("failed to add co-author", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add co-author"})
+	default:
+		c.JSON(http.StatusOK, coAuthor)
+	}
+}
+
+func (h *Handlers) RemoveCoAuthorHandler(c *gin.Context) {
+	err := h.ProblemService.ProblemRepo.RemoveCoAuthor(c.Param("uuid"), c.Param("user"))
+	if errors.Is(err, problems.ErrCoAuthorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "co-author not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to remove co-author", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove co-author"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "co-author removed successfully"})
+}
+
+// GetUsersHandler lists all users with their roles and permissions
+func (h *Handlers) GetUsersHandler(c *gin.Context) {
+	users, err := h.UserService.UserRepo.ListUsers()
+	if err != nil {
+		h.Logger.Error("failed to list users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list users"})
+		return
+	}
+
+	permissions := gin.H{}
+	for _, role := range []string{auth.RoleUser, auth.RoleAuthor, auth.RoleAdmin} {
+		permissions[role] = auth.Permissions(role)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users, "permissions": permissions})
+}
+
+// SetUserRoleHandler changes the role of a user. The new role applies to the next
+// request of the user: it is checked against the database, not the token.
+func (h *Handlers) SetUserRoleHandler(c *gin.Context) {
+	var req user.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+	if !auth.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": auth.ErrInvalidRole.Error()})
+		return
+	}
+
+	userUUID := c.Param("uuid")
+	// Администратор не может понизить сам себя и остаться без доступа
+	if userUUID == c.GetString("userID") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot change your own role"})
+		return
+	}
+
+	err := h.UserService.UserRepo.SetUserRole(userUUID, req.Role)
+	if errors.Is(err, user.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to set user role", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set user role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role updated successfully"})
+}
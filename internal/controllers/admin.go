@@ -3,8 +3,13 @@ package controllers
 import (
 	"diplom/internal/problems"
 	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -24,20 +29,78 @@ func (h *Handlers) CreateProblemHandler(c *gin.Context) {
 		return
 	}
 
+	ioSettings, err := req.IOSettings.Normalize()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.IOSettings = ioSettings
+
+	tags, err := problems.NormalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := req.RenderDescription(); err != nil {
+		h.Logger.Error("failed to render description", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to render description"})
+		return
+	}
+
 	problemUUID := uuid.New().String()
 
-	err := h.ProblemService.ProblemRepo.(interface {
-		AddProblem(uuid, name, difficulty, description string) error
-	}).AddProblem(problemUUID, req.Name, req.Difficulty, req.Description)
+	err = h.ProblemService.ProblemRepo.AddProblem(problemUUID, c.GetString("userID"), req)
 	if err != nil {
 		h.Logger.Error("failed to add problem", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add problem"})
 		return
 	}
 
+	if len(tags) > 0 {
+		if err := h.ProblemService.ProblemRepo.SetProblemTags(problemUUID, tags); err != nil {
+			h.Logger.Error("failed to set problem tags", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set problem tags"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "problem added successfully", "uuid": problemUUID})
 }
 
+// SetProblemTagsHandler replaces the tags of a problem
+func (h *Handlers) SetProblemTagsHandler(c *gin.Context) {
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(c.Param("uuid"), c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		return
+	}
+
+	var req problems.SetTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	tags, err := problems.NormalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.ProblemService.ProblemRepo.SetProblemTags(problem.UUID, tags); err != nil {
+		h.Logger.Error("failed to set problem tags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set problem tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "tags updated successfully", "tags": tags})
+}
+
 func (h *Handlers) AddTestcaseHandler(c *gin.Context) {
 	problemUUID := c.Param("uuid")
 	if problemUUID == "" {
@@ -55,14 +118,46 @@ func (h *Handlers) AddTestcaseHandler(c *gin.Context) {
 		return
 	}
 
+	// Large tests are uploaded as multipart files, small ones may be sent as JSON
 	var req problems.CreateTestcaseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.Logger.Error("failed to bind testcase request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+	var input, output io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		inputFile, err := openFormFile(c, "input")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "input file is required"})
+			return
+		}
+		defer inputFile.Close()
+		outputFile, err := openFormFile(c, "output")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "output file is required"})
+			return
+		}
+		defer outputFile.Close()
+		input, output = inputFile, outputFile
+
+		if raw := c.PostForm("subtask_id"); raw != "" {
+			id, err := strconv.Atoi(raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subtask ID format"})
+				return
+			}
+			req.SubtaskID = &id
+		}
+	} else {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.Logger.Error("failed to bind testcase request", zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+			return
+		}
+		input, output = strings.NewReader(req.Input), strings.NewReader(req.Output)
+	}
+
+	if !h.checkSubtask(c, problem.UUID, req.SubtaskID) {
 		return
 	}
 
-	err = h.ProblemService.ProblemRepo.AddTestcase(problem.UUID, req.Input, req.Output)
+	err = h.ProblemService.AddTestcase(c.Request.Context(), problem.UUID, input, output, req.SubtaskID)
 	if err != nil {
 		h.Logger.Error("failed to add test case", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add test case"})
@@ -72,6 +167,147 @@ func (h *Handlers) AddTestcaseHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "test case added successfully"})
 }
 
+// UploadTestArchiveHandler adds tests from a zip archive of NN.in/NN.out or NN/NN.a pairs.
+// With mode=replace the existing tests of the problem are removed.
+func (h *Handlers) UploadTestArchiveHandler(c *gin.Context) {
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(c.Param("uuid"), c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		return
+	}
+
+	var replace bool
+	switch c.DefaultPostForm("mode", "append") {
+	case "append":
+	case "replace":
+		replace = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be append or replace"})
+		return
+	}
+
+	var subtaskID *int
+	if raw := c.PostForm("subtask_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subtask ID format"})
+			return
+		}
+		subtaskID = &id
+	}
+	if !h.checkSubtask(c, problem.UUID, subtaskID) {
+		return
+	}
+
+	header, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "archive file is required"})
+		return
+	}
+	if header.Size > problems.MaxTestArchiveSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "archive is too large"})
+		return
+	}
+	archive, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read archive"})
+		return
+	}
+	defer archive.Close()
+
+	count, err := h.ProblemService.UploadTestArchive(c.Request.Context(), problem.UUID, archive, header.Size, subtaskID, replace)
+	var archiveErr *problems.TestArchiveError
+	if errors.As(err, &archiveErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid test archive", "files": archiveErr.Files})
+		return
+	} else if errors.Is(err, problems.ErrInvalidTestArchive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to upload test archive", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload tests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "tests uploaded successfully", "count": count, "replaced": replace})
+}
+
+// checkSubtask verifies that the optional subtask belongs to the problem and writes an error response otherwise
+func (h *Handlers) checkSubtask(c *gin.Context, problemUUID string, subtaskID *int) bool {
+	if subtaskID == nil {
+		return true
+	}
+	subtasks, err := h.ProblemService.ProblemRepo.GetSubtasksByProblemUUID(problemUUID)
+	if err != nil {
+		h.Logger.Error("failed to get subtasks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subtasks"})
+		return false
+	}
+	if !slices.ContainsFunc(subtasks, func(st problems.Subtask) bool { return st.ID == *subtaskID }) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "subtask does not belong to the problem"})
+		return false
+	}
+	return true
+}
+
+func openFormFile(c *gin.Context, name string) (multipart.File, error) {
+	header, err := c.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return header.Open()
+}
+
+// GetTestcaseDataHandler downloads the input or expected output of a test case
+func (h *Handlers) GetTestcaseDataHandler(c *gin.Context) {
+	testcaseID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid testcase ID format"})
+		return
+	}
+
+	testcase, err := h.ProblemService.ProblemRepo.GetTestCaseByID(testcaseID)
+	if errors.Is(err, problems.ErrTestCaseNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "testcase not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to get testcase", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get testcase"})
+		return
+	}
+
+	var key, inline string
+	switch c.Param("kind") {
+	case "input":
+		key, inline = testcase.InputKey, testcase.Input
+	case "output":
+		key, inline = testcase.OutputKey, testcase.Output
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be input or output"})
+		return
+	}
+
+	if key == "" {
+		c.String(http.StatusOK, inline)
+		return
+	}
+
+	data, err := h.ProblemService.TestData.Open(c.Request.Context(), key)
+	if err != nil {
+		h.Logger.Error("failed to open test data", zap.String("key", key), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open test data"})
+		return
+	}
+	defer data.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%d.%s", testcase.ID, c.Param("kind")))
+	c.DataFromReader(http.StatusOK, -1, "text/plain; charset=utf-8", data, nil)
+}
+
 func (h *Handlers) GetProblemTestcasesHandler(c *gin.Context) {
 	problemUUID := c.Param("uuid")
 	if problemUUID == "" {
@@ -162,3 +398,118 @@ func (h *Handlers) DeleteProblemHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Problem and all its testcases deleted successfully"})
 }
+
+func (h *Handlers) GetSubtasksHandler(c *gin.Context) {
+	problemUUID := c.Param("uuid")
+	if problemUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem UUID is required"})
+		return
+	}
+
+	subtasks, err := h.ProblemService.ProblemRepo.GetSubtasksByProblemUUID(problemUUID)
+	if err != nil {
+		h.Logger.Error("failed to get subtasks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subtasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subtasks)
+}
+
+func (h *Handlers) AddSubtaskHandler(c *gin.Context) {
+	problemUUID := c.Param("uuid")
+	if problemUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem UUID is required"})
+		return
+	}
+
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(problemUUID, c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		return
+	}
+
+	var req problems.CreateSubtaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Logger.Error("failed to bind subtask request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	req.Scoring, err = problems.ValidateScoring(req.Scoring)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.ProblemService.ProblemRepo.AddSubtask(problem.UUID, req)
+	if err != nil {
+		h.Logger.Error("failed to add subtask", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add subtask"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "subtask added successfully", "id": id})
+}
+
+func (h *Handlers) DeleteSubtaskHandler(c *gin.Context) {
+	subtaskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subtask ID format"})
+		return
+	}
+
+	err = h.ProblemService.ProblemRepo.DeleteSubtask(subtaskID)
+	if errors.Is(err, problems.ErrSubtaskNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "subtask not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete subtask", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete subtask"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "subtask deleted successfully"})
+}
+
+// GetProblemSimilarityHandler lists suspiciously similar pairs of accepted submissions
+func (h *Handlers) GetProblemSimilarityHandler(c *gin.Context) {
+	problemUUID := c.Param("uuid")
+	if problemUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem UUID is required"})
+		return
+	}
+
+	minSimilarity := h.PlagiarismService.Threshold()
+	if raw := c.Query("min"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 || value > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min must be a number between 0 and 1"})
+			return
+		}
+		minSimilarity = value
+	}
+
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(problemUUID, c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		return
+	}
+
+	pairs, err := h.PlagiarismService.Repo.GetSimilarities(problem.UUID, minSimilarity)
+	if err != nil {
+		h.Logger.Error("failed to get similarities", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get similarities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"threshold": minSimilarity, "pairs": pairs})
+}
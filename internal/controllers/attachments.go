@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"diplom/internal/problems"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UploadAttachmentHandler stores a file of a problem (multipart field "file").
+// The optional "name" field sets the name in the URL, the file name is used otherwise.
+func (h *Handlers) UploadAttachmentHandler(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if header.Size > problems.MaxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": problems.ErrAttachmentTooLarge.Error()})
+		return
+	}
+
+	name := c.PostForm("name")
+	if name == "" {
+		name = filepath.Base(header.Filename)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.Logger.Error("failed to open uploaded attachment", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.ProblemService.UploadAttachment(c.Request.Context(), c.Param("uuid"), name, file, header.Size)
+	switch {
+	case errors.Is(err, problems.ErrProblemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+	case errors.Is(err, problems.ErrAttachmentTooLarge) || errors.Is(err, problems.ErrAttachmentQuotaExceeded):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrAttachmentType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrInvalidAttachmentName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		h.Logger.Error("failed to upload attachment", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to upload attachment"})
+	default:
+		c.JSON(http.StatusOK, attachment)
+	}
+}
+
+// GetAttachmentsHandler lists the attachments of a problem with their URLs
+func (h *Handlers) GetAttachmentsHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	attachments, err := h.ProblemService.GetAttachments(problem.UUID)
+	if err != nil {
+		h.Logger.Error("failed to get attachments", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get attachments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// GetAttachmentHandler serves an attachment by its stable URL
+func (h *Handlers) GetAttachmentHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	attachment, data, err := h.ProblemService.OpenAttachment(c.Request.Context(), problem.UUID, c.Param("name"))
+	if errors.Is(err, problems.ErrAttachmentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to open attachment", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open attachment"})
+		return
+	}
+	defer data.Close()
+
+	// Ключ объекта — хеш содержимого, поэтому подходит как ETag
+	etag := `"` + attachment.Key + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	disposition := "attachment"
+	if problems.IsInlineType(attachment.ContentType) {
+		disposition = "inline"
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, data, map[string]string{
+		"Content-Disposition":     fmt.Sprintf("%s; filename=%q", disposition, attachment.Name),
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "default-src 'none'; sandbox",
+	})
+}
+
+func (h *Handlers) DeleteAttachmentHandler(c *gin.Context) {
+	err := h.ProblemService.ProblemRepo.DeleteAttachment(c.Param("uuid"), c.Param("name"))
+	if errors.Is(err, problems.ErrAttachmentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete attachment", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete attachment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "attachment deleted successfully"})
+}
@@ -3,8 +3,8 @@ package controllers
 import (
 	"diplom/internal/auth"
 	"errors"
+	"io"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -29,16 +29,87 @@ func (h *Handlers) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	token, err := h.AuthService.CreateSession(req.Username, userID, role)
+	tokens, err := h.AuthService.CreateSession(req.Username, userID, role, clientInfo(c))
 	if err != nil {
 		h.AuthService.Logger.Error("error in create session", zap.Error(err))
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to create (rewrite) session"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to create session"})
 		return
 	}
 
 	h.AuthService.Logger.Info("user loggined", zap.String("username", req.Username))
-	c.SetCookie("session", token, int(time.Until(auth.CookieExpirationTime).Seconds()), "/", "", false, true)
-	c.JSON(http.StatusOK, auth.LoginResponse{Token: token})
+	setSessionCookies(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RefreshHandler выдаёт новую пару токенов по refresh token из тела запроса или cookie
+func (h *Handlers) RefreshHandler(c *gin.Context) {
+	var req auth.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = c.Cookie(refreshCookie)
+	}
+
+	tokens, err := h.AuthService.Refresh(req.RefreshToken, clientInfo(c))
+	if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrSessionExpired) ||
+		errors.Is(err, auth.ErrRefreshTokenReused) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authorized: " + err.Error()})
+		return
+	} else if err != nil {
+		h.AuthService.Logger.Error("error in refresh session", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+		return
+	}
+
+	setSessionCookies(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LogoutHandler завершает текущую сессию
+func (h *Handlers) LogoutHandler(c *gin.Context) {
+	err := h.AuthService.Logout(c.GetString("sessionID"))
+	if err != nil && !errors.Is(err, auth.ErrSessionNotFound) {
+		h.AuthService.Logger.Error("error in logout", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// LogoutAllHandler завершает все сессии пользователя, включая текущую
+func (h *Handlers) LogoutAllHandler(c *gin.Context) {
+	revoked, err := h.AuthService.RevokeUserSessions(c.GetString("userID"))
+	if err != nil {
+		h.AuthService.Logger.Error("error in logout from all sessions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
+		return
+	}
+
+	clearSessionCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out from all sessions", "revoked": revoked})
+}
+
+// refreshCookie доступна только эндпоинтам /api/auth
+const refreshCookie = "refresh_token"
+
+// setSessionCookies сохраняет токены в cookie: токен доступа нужен для запросов,
+// которые браузер выполняет сам (вложения, SSE)
+func setSessionCookies(c *gin.Context, tokens auth.LoginResponse) {
+	c.SetCookie("session", tokens.Token, int(auth.AccessTokenTTL.Seconds()), "/", "", false, true)
+	c.SetCookie(refreshCookie, tokens.RefreshToken, int(auth.RefreshTokenTTL.Seconds()), "/api/auth", "", false, true)
+}
+
+func clearSessionCookies(c *gin.Context) {
+	c.SetCookie("session", "", -1, "/", "", false, true)
+	c.SetCookie(refreshCookie, "", -1, "/api/auth", "", false, true)
+}
+
+func clientInfo(c *gin.Context) auth.ClientInfo {
+	return auth.ClientInfo{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
 }
 
 // SignupHandler обрабатывает запрос на регистрацию
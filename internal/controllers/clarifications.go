@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"diplom/internal/auth"
+	"diplom/internal/contests"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseHeartbeat keeps idle event streams open behind proxies
+const sseHeartbeat = 30 * time.Second
+
+// GetClarificationsHandler lists clarifications visible to the user; contest managers see all of them
+func (h *Handlers) GetClarificationsHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	clarifications, err := h.ContestService.GetClarifications(id, c.GetString("userID"), hasPermission(c, auth.PermContestsManage))
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"clarifications": clarifications})
+}
+
+// AskClarificationHandler sends a participant's question to the judges
+func (h *Handlers) AskClarificationHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	var req contests.AskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	clarification, err := h.ContestService.Ask(id, c.GetString("userID"), req)
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, clarification)
+}
+
+// AnswerClarificationHandler answers a question privately or to all participants
+func (h *Handlers) AnswerClarificationHandler(c *gin.Context) {
+	clarificationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid clarification ID format"})
+		return
+	}
+
+	var req contests.AnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	clarification, err := h.ContestService.Answer(clarificationID, c.GetString("userID"), req)
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, clarification)
+}
+
+// AnnounceHandler publishes an announcement to all participants of the contest
+func (h *Handlers) AnnounceHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	var req contests.AnnounceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	clarification, err := h.ContestService.Announce(id, c.GetString("userID"), req)
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, clarification)
+}
+
+// ContestEventsHandler streams announcements and answers to the user over SSE.
+// Events missed while disconnected are available from GetClarificationsHandler.
+func (h *Handlers) ContestEventsHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	events, unsubscribe, err := h.ContestService.Subscribe(id, c.GetString("userID"), hasPermission(c, auth.PermContestsManage))
+	if h.contestError(c, err) {
+		return
+	}
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-events:
+			c.SSEvent(event.Type, event.Clarification)
+		case <-heartbeat.C:
+			_, _ = io.WriteString(w, ": ping\n\n")
+		}
+		return true
+	})
+}
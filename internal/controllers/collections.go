@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"diplom/internal/auth"
+	"diplom/internal/problems"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetCollectionsHandler returns all collections with the user's progress
+func (h *Handlers) GetCollectionsHandler(c *gin.Context) {
+	collections, err := h.ProblemService.GetCollections(c.GetString("userID"))
+	if err != nil {
+		h.Logger.Error("failed to get collections", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get collections"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"collections": collections})
+}
+
+// GetCollectionHandler returns a collection with its problems in order
+func (h *Handlers) GetCollectionHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID format"})
+		return
+	}
+
+	collection, err := h.ProblemService.GetCollection(c.GetString("userID"), id, hasPermission(c, auth.PermCollectionsManage))
+	if errors.Is(err, problems.ErrCollectionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to get collection", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// CreateCollectionHandler creates a collection of problems
+func (h *Handlers) CreateCollectionHandler(c *gin.Context) {
+	var req problems.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	id, err := h.ProblemService.CreateCollection(req)
+	if h.collectionError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "collection created successfully", "id": id})
+}
+
+// UpdateCollectionHandler replaces a collection, its problems and prerequisites
+func (h *Handlers) UpdateCollectionHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID format"})
+		return
+	}
+
+	var req problems.CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	if h.collectionError(c, h.ProblemService.UpdateCollection(id, req)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "collection updated successfully"})
+}
+
+// collectionError writes the response for a failed collection change and reports whether there was an error
+func (h *Handlers) collectionError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, problems.ErrCollectionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+	case errors.Is(err, problems.ErrProblemNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrPrerequisiteNotFound) || errors.Is(err, problems.ErrDuplicateCollectionRef):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrPrerequisiteCycle):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("failed to save collection", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save collection"})
+	}
+	return true
+}
+
+func (h *Handlers) DeleteCollectionHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid collection ID format"})
+		return
+	}
+
+	err = h.ProblemService.ProblemRepo.DeleteCollection(id)
+	if errors.Is(err, problems.ErrCollectionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "collection not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete collection", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "collection deleted successfully"})
+}
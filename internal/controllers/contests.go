@@ -0,0 +1,310 @@
+package controllers
+
+import (
+	"diplom/internal/auth"
+	"diplom/internal/contests"
+	"diplom/internal/problems"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// contestID parses the contest ID from the path. On failure the error response is already written.
+func contestID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid contest ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// GetContestsHandler returns all contests with the user's registration
+func (h *Handlers) GetContestsHandler(c *gin.Context) {
+	list, err := h.ContestService.GetContests(c.GetString("userID"))
+	if err != nil {
+		h.Logger.Error("failed to get contests", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get contests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contests": list})
+}
+
+// GetContestHandler returns a contest; its problems are listed once it has started
+func (h *Handlers) GetContestHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	contest, err := h.ContestService.GetContest(id, c.GetString("userID"), hasPermission(c, auth.PermContestsManage))
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, contest)
+}
+
+// bindRegisterRequest reads the optional team of a registration; the body may be empty
+func bindRegisterRequest(c *gin.Context) (contests.RegisterRequest, bool) {
+	var req contests.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return req, false
+	}
+	return req, true
+}
+
+// RegisterContestHandler registers the user, or their team in a team contest, as a participant
+func (h *Handlers) RegisterContestHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+	req, ok := bindRegisterRequest(c)
+	if !ok {
+		return
+	}
+
+	if h.contestError(c, h.ContestService.Register(id, c.GetString("userID"), req)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "registered for the contest"})
+}
+
+// StartVirtualContestHandler starts a virtual run of a finished contest
+func (h *Handlers) StartVirtualContestHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	req, ok := bindRegisterRequest(c)
+	if !ok {
+		return
+	}
+
+	contest, err := h.ContestService.StartVirtual(id, c.GetString("userID"), req)
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, contest)
+}
+
+// GetContestProblemHandler returns the statement of a contest problem to a participant
+func (h *Handlers) GetContestProblemHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+	problemUUID := c.Param("uuid")
+	if _, err := uuid.Parse(problemUUID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid problem UUID"})
+		return
+	}
+
+	userID := c.GetString("userID")
+	problem, contestProblem, err := h.ContestService.GetProblem(id, userID, problemUUID)
+	if h.contestError(c, err) {
+		return
+	}
+
+	if err := h.ProblemService.Localize(problem, preferredLocales(c)); err != nil {
+		h.Logger.Error("failed to get translations", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get translations"})
+		return
+	}
+	problem.Subtasks, err = h.ProblemService.ProblemRepo.GetSubtasksByProblemUUID(problem.UUID)
+	if err != nil {
+		h.Logger.Error("failed to get subtasks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subtasks"})
+		return
+	}
+	problem.StarterCode, err = h.ProblemService.GetStarterCode(problem)
+	if err != nil {
+		h.Logger.Error("failed to get starter code", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get starter code"})
+		return
+	}
+	problem.Drafts, err = h.ProblemService.ProblemRepo.GetDrafts(userID, problem.UUID)
+	if err != nil {
+		h.Logger.Error("failed to get drafts", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get drafts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contest_id": id, "label": contestProblem.Label, "problem": problem})
+}
+
+// SubmitContestSolutionHandler judges a participant's solution while the contest is running
+func (h *Handlers) SubmitContestSolutionHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+	problemUUID := c.Param("uuid")
+	if _, err := uuid.Parse(problemUUID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid problem UUID"})
+		return
+	}
+
+	var req problems.SolutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	req.ProblemUUID = problemUUID
+
+	result, err := h.ContestService.Submit(c.Request.Context(), id, c.GetString("userID"), req)
+	switch {
+	case errors.Is(err, problems.ErrCompilationFailed) || errors.Is(err, problems.ErrExecutionFailed):
+		c.JSON(http.StatusBadRequest, result)
+		return
+	case errors.Is(err, problems.ErrTestCasesNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case h.contestError(c, err):
+		return
+	}
+
+	if result.Status == problems.StatusSuccess {
+		h.PlagiarismService.Enqueue(problemUUID)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetContestStandingsHandler returns the ICPC-style scoreboard of the contest.
+// Contest managers see the live board unless they ask for the public one with ?public=true.
+func (h *Handlers) GetContestStandingsHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	live := hasPermission(c, auth.PermContestsManage) && c.Query("public") != "true"
+	standings, err := h.ContestService.GetStandings(id, live)
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+// GetVirtualStandingsHandler returns the scoreboard at the elapsed time of the user's virtual run
+func (h *Handlers) GetVirtualStandingsHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	standings, err := h.ContestService.GetVirtualStandings(id, c.GetString("userID"))
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
+
+// UnfreezeContestHandler reveals the next frozen result in resolver order, or all of them with ?all=true
+func (h *Handlers) UnfreezeContestHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.ContestService.Unfreeze(id, c.Query("all") == "true")
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateContestHandler creates a contest
+func (h *Handlers) CreateContestHandler(c *gin.Context) {
+	var req contests.ContestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	id, err := h.ContestService.CreateContest(c.GetString("userID"), req)
+	if h.contestError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "contest created successfully", "id": id})
+}
+
+// UpdateContestHandler replaces a contest and its problems
+func (h *Handlers) UpdateContestHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	var req contests.ContestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	if h.contestError(c, h.ContestService.UpdateContest(id, req)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "contest updated successfully"})
+}
+
+func (h *Handlers) DeleteContestHandler(c *gin.Context) {
+	id, ok := contestID(c)
+	if !ok {
+		return
+	}
+
+	if h.contestError(c, h.ContestService.Repo.DeleteContest(id)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "contest deleted successfully"})
+}
+
+// contestError writes the response for a failed contest operation and reports whether there was an error
+func (h *Handlers) contestError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, contests.ErrContestNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "contest not found"})
+	case errors.Is(err, contests.ErrProblemNotInContest) || errors.Is(err, contests.ErrNoVirtualRun) ||
+		errors.Is(err, contests.ErrClarificationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, contests.ErrNotRegistered) || errors.Is(err, contests.ErrNotTeamMember):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrProblemNotFound) || errors.Is(err, contests.ErrInvalidContestTime) ||
+		errors.Is(err, contests.ErrInvalidFreezeTime) || errors.Is(err, contests.ErrDuplicateProblem) ||
+		errors.Is(err, contests.ErrTooManyContestProblems) || errors.Is(err, contests.ErrEmptyClarification) ||
+		errors.Is(err, contests.ErrClarificationTooLong) || errors.Is(err, contests.ErrTeamRequired) ||
+		errors.Is(err, contests.ErrTeamNotAllowed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, contests.ErrContestNotStarted) || errors.Is(err, contests.ErrContestNotRunning) ||
+		errors.Is(err, contests.ErrContestFinished) || errors.Is(err, contests.ErrAlreadyRegistered) ||
+		errors.Is(err, contests.ErrContestNotFinished) || errors.Is(err, contests.ErrContestNotFrozen) ||
+		errors.Is(err, contests.ErrVirtualRunExists) || errors.Is(err, contests.ErrMemberAlreadyRegistered) ||
+		errors.Is(err, contests.ErrTeamModeLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("contest operation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+	return true
+}
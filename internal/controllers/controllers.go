@@ -2,15 +2,21 @@ package controllers
 
 import (
 	"diplom/internal/auth"
+	"diplom/internal/contests"
+	"diplom/internal/plagiarism"
 	"diplom/internal/problems"
+	"diplom/internal/teams"
 	"diplom/internal/user"
 
 	"go.uber.org/zap"
 )
 
 type Handlers struct {
-	AuthService    *auth.AuthService
-	ProblemService *problems.ProblemService
-	UserService    *user.UserService
-	Logger         *zap.Logger
+	AuthService       *auth.AuthService
+	ProblemService    *problems.ProblemService
+	UserService       *user.UserService
+	PlagiarismService *plagiarism.Service
+	ContestService    *contests.Service
+	TeamService       *teams.Service
+	Logger            *zap.Logger
 }
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"diplom/internal/problems"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetHintsHandler returns the hints the user has already revealed
+func (h *Handlers) GetHintsHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	hints, err := h.ProblemService.GetHints(c.GetString("userID"), problem.UUID)
+	if err != nil {
+		h.Logger.Error("failed to get hints", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get hints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hints)
+}
+
+// NextHintHandler reveals the next hint of the problem to the user
+func (h *Handlers) NextHintHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	hints, err := h.ProblemService.RevealNextHint(c.GetString("userID"), problem.UUID)
+	if errors.Is(err, problems.ErrNoMoreHints) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to reveal hint", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reveal hint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hints)
+}
+
+// GiveUpHandler records that the user gave up and returns the editorial if there is one
+func (h *Handlers) GiveUpHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("userID")
+	if err := h.ProblemService.ProblemRepo.GiveUp(userID, problem.UUID); err != nil {
+		h.Logger.Error("failed to give up", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to give up"})
+		return
+	}
+
+	editorial, err := h.ProblemService.GetEditorial(userID, problem)
+	if errors.Is(err, problems.ErrEditorialNotFound) {
+		c.JSON(http.StatusOK, gin.H{"message": "problem marked as given up", "editorial": nil})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to get editorial", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get editorial"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "problem marked as given up", "editorial": editorial})
+}
+
+// GetEditorialHandler returns the editorial after an accepted solution or giving up
+func (h *Handlers) GetEditorialHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	editorial, err := h.ProblemService.GetEditorial(c.GetString("userID"), problem)
+	switch {
+	case errors.Is(err, problems.ErrEditorialLocked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrEditorialNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "editorial not found"})
+	case err != nil:
+		h.Logger.Error("failed to get editorial", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get editorial"})
+	default:
+		c.JSON(http.StatusOK, editorial)
+	}
+}
+
+// SetEditorialHandler creates or replaces the editorial of a problem
+func (h *Handlers) SetEditorialHandler(c *gin.Context) {
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(c.Param("uuid"), c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		return
+	}
+
+	var req problems.SetEditorialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	content, err := problems.RenderStatement(req.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to render editorial"})
+		return
+	}
+
+	if err := h.ProblemService.ProblemRepo.SetEditorial(problem.UUID, req.Content, content); err != nil {
+		h.Logger.Error("failed to set editorial", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set editorial"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "editorial saved successfully"})
+}
+
+// GetProblemEditorialHandler returns the editorial with its source for editing
+func (h *Handlers) GetProblemEditorialHandler(c *gin.Context) {
+	editorial, err := h.ProblemService.ProblemRepo.GetEditorial(c.Param("uuid"))
+	if errors.Is(err, problems.ErrEditorialNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "editorial not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to get editorial", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get editorial"})
+		return
+	}
+
+	c.JSON(http.StatusOK, editorial)
+}
+
+func (h *Handlers) DeleteEditorialHandler(c *gin.Context) {
+	err := h.ProblemService.ProblemRepo.DeleteEditorial(c.Param("uuid"))
+	if errors.Is(err, problems.ErrEditorialNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "editorial not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete editorial", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete editorial"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "editorial deleted successfully"})
+}
+
+// GetProblemHintsHandler returns all hints of a problem with their sources
+func (h *Handlers) GetProblemHintsHandler(c *gin.Context) {
+	hints, err := h.ProblemService.ProblemRepo.GetHints(c.Param("uuid"))
+	if err != nil {
+		h.Logger.Error("failed to get hints", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get hints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hints)
+}
+
+func (h *Handlers) AddHintHandler(c *gin.Context) {
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(c.Param("uuid"), c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		return
+	}
+
+	var req problems.CreateHintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	content, err := problems.RenderStatement(req.Content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to render hint"})
+		return
+	}
+
+	id, err := h.ProblemService.ProblemRepo.AddHint(problem.UUID, req.Position, req.Content, content)
+	if err != nil {
+		h.Logger.Error("failed to add hint", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add hint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "hint added successfully", "id": id})
+}
+
+func (h *Handlers) DeleteHintHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid hint ID format"})
+		return
+	}
+
+	err = h.ProblemService.ProblemRepo.DeleteHint(id)
+	if errors.Is(err, problems.ErrHintNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "hint not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete hint", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete hint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "hint deleted successfully"})
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"diplom/internal/auth"
+	"diplom/internal/problems"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// hasPermission reports whether the role of the request grants the permission
+func hasPermission(c *gin.Context, permission string) bool {
+	return auth.HasPermission(c.GetString("role"), permission)
+}
+
+// canEditProblem reports whether the user may edit the problem: admins may edit
+// any problem, authors only the ones they own or co-author
+func (h *Handlers) canEditProblem(c *gin.Context, problemUUID string) bool {
+	allowed, err := h.AuthService.CanAccessProblem(c.GetString("role"), c.GetString("userID"), problemUUID, auth.ActionEdit)
+	if err != nil && !errors.Is(err, auth.ErrResourceNotFound) {
+		h.Logger.Error("failed to check problem access", zap.Error(err))
+	}
+	return allowed
+}
+
+// getVisibleProblem fetches the problem and hides unpublished problems from users
+// who cannot edit them. On failure the error response is already written.
+func (h *Handlers) getVisibleProblem(c *gin.Context, problemUUID string) (*problems.Problem, bool) {
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(problemUUID, c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return nil, false
+	} else if err != nil {
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		return nil, false
+	}
+
+	if problem.Status != problems.ProblemStatusPublished && !h.canEditProblem(c, problem.UUID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return nil, false
+	}
+	return problem, true
+}
+
+// SetProblemStatusHandler publishes, schedules, unpublishes or archives a problem.
+// Publishing requires tests and an author solution that passes all of them.
+func (h *Handlers) SetProblemStatusHandler(c *gin.Context) {
+	var req problems.SetStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	problemUUID := c.Param("uuid")
+	err := h.ProblemService.SetStatus(c.Request.Context(), problemUUID, req)
+	switch {
+	case errors.Is(err, problems.ErrProblemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+	case errors.Is(err, problems.ErrTestCasesNotFound):
+		c.JSON(http.StatusConflict, gin.H{"error": "problem has no tests"})
+	case errors.Is(err, problems.ErrNoAuthorSolution) || errors.Is(err, problems.ErrAuthorSolutionFailed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrInvalidProblemStatus) || errors.Is(err, problems.ErrInvalidPublishTime):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		h.Logger.Error("failed to set problem status", zap.String("uuid", problemUUID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set problem status"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "problem status updated", "uuid": problemUUID})
+	}
+}
+
+// AddAuthorSolutionHandler adds a reference solution used to check the problem before publishing
+func (h *Handlers) AddAuthorSolutionHandler(c *gin.Context) {
+	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(c.Param("uuid"), c.GetString("userID"))
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		return
+	}
+
+	var req problems.CreateAuthorSolutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+	if _, err := problems.GetLanguageHandler(req.Language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.ProblemService.ProblemRepo.AddAuthorSolution(problem.UUID, req.Language, req.Code)
+	if err != nil {
+		h.Logger.Error("failed to add author solution", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add author solution"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "author solution added successfully", "id": id})
+}
+
+// GetAuthorSolutionsHandler returns reference solutions of a problem with their last verdicts
+func (h *Handlers) GetAuthorSolutionsHandler(c *gin.Context) {
+	solutions, err := h.ProblemService.ProblemRepo.GetAuthorSolutions(c.Param("uuid"))
+	if err != nil {
+		h.Logger.Error("failed to get author solutions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get author solutions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, solutions)
+}
+
+func (h *Handlers) DeleteAuthorSolutionHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid author solution ID format"})
+		return
+	}
+
+	err = h.ProblemService.ProblemRepo.DeleteAuthorSolution(id)
+	if errors.Is(err, problems.ErrAuthorSolutionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "author solution not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete author solution", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete author solution"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "author solution deleted successfully"})
+}
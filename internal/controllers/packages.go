@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"diplom/internal/problems"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ImportProblemHandler creates a problem from an uploaded package archive (multipart field "package")
+func (h *Handlers) ImportProblemHandler(c *gin.Context) {
+	header, err := c.FormFile("package")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "package file is required"})
+		return
+	}
+	if header.Size > problems.MaxPackageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "package is too large"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.Logger.Error("failed to open uploaded package", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read package"})
+		return
+	}
+	defer file.Close()
+
+	result, err := h.ProblemService.ImportPackage(c.Request.Context(), file, header.Size, c.GetString("userID"), uuid.New().String())
+	if errors.Is(err, problems.ErrInvalidPackage) || errors.Is(err, problems.ErrInvalidIOSettings) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to import problem package", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import problem"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExportProblemHandler returns the problem as a package archive
+func (h *Handlers) ExportProblemHandler(c *gin.Context) {
+	problemUUID := c.Param("uuid")
+
+	// Архив собирается во временный файл, чтобы ошибка не оборвала уже начатый ответ
+	tmp, err := os.CreateTemp("", "problem-*.zip")
+	if err != nil {
+		h.Logger.Error("failed to create temp file", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export problem"})
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	err = h.ProblemService.ExportPackage(c.Request.Context(), problemUUID, tmp)
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to export problem", zap.String("uuid", problemUUID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export problem"})
+		return
+	}
+
+	c.FileAttachment(tmp.Name(), fmt.Sprintf("%s.zip", problemUUID))
+}
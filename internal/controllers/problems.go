@@ -2,9 +2,12 @@ package controllers
 
 import (
 	"database/sql"
+	"diplom/internal/auth"
 	"diplom/internal/problems"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -37,6 +40,10 @@ func (h *Handlers) SubmitSolutionHandler(c *gin.Context) {
 		return
 	}
 
+	if _, ok := h.getVisibleProblem(c, problemUUID); !ok {
+		return
+	}
+
 	// Process solution
 	result, err := h.ProblemService.ProcessSolution(c.Request.Context(), req, c.GetString("userID"))
 
@@ -72,6 +79,10 @@ func (h *Handlers) SubmitSolutionHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get solution statistics"})
 	}
 
+	if result.Status == problems.StatusSuccess {
+		h.PlagiarismService.Enqueue(problemUUID)
+	}
+
 	c.JSON(http.StatusOK, result)
 }
 
@@ -87,10 +98,35 @@ func (h *Handlers) GetProblemHandler(c *gin.Context) {
 
 	userID := c.GetString("userID")
 	// Get problem details
-	problem, err := h.ProblemService.ProblemRepo.GetProblemByUUID(problemUUID, userID)
+	problem, ok := h.getVisibleProblem(c, problemUUID)
+	if !ok {
+		return
+	}
+
+	if err := h.ProblemService.Localize(problem, preferredLocales(c)); err != nil {
+		h.Logger.Error("failed to get translations", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get translations"})
+		return
+	}
+
+	var err error
+	problem.Subtasks, err = h.ProblemService.ProblemRepo.GetSubtasksByProblemUUID(problem.UUID)
 	if err != nil {
-		h.Logger.Error("failed to get problem", zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		h.Logger.Error("failed to get subtasks", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get subtasks"})
+		return
+	}
+
+	problem.StarterCode, err = h.ProblemService.GetStarterCode(problem)
+	if err != nil {
+		h.Logger.Error("failed to get starter code", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get starter code"})
+		return
+	}
+	problem.Drafts, err = h.ProblemService.ProblemRepo.GetDrafts(userID, problem.UUID)
+	if err != nil {
+		h.Logger.Error("failed to get drafts", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get drafts"})
 		return
 	}
 
@@ -123,14 +159,101 @@ func (h *Handlers) GetProblemHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, problem)
 }
 
+// GetAllProblemsHandler returns a page of problem summaries.
+// Query parameters: difficulty and tags (comma separated), status (solved/unsolved),
+// q (full-text search), sort (id/newest/acceptance), limit and cursor.
+// Admins may also filter by problem_status (draft/published/archived).
 func (h *Handlers) GetAllProblemsHandler(c *gin.Context) {
-	problems, err := h.ProblemService.ProblemRepo.GetAllProblems(c.GetString("userID"))
+	query := problems.ProblemListQuery{
+		Difficulties: splitQueryList(c.Query("difficulty")),
+		Status:       c.Query("status"),
+		Search:       c.Query("q"),
+		Sort:         c.Query("sort"),
+		Locales:      preferredLocales(c),
+	}
+
+	// Черновики и архив видны администраторам, а авторам — только свои
+	switch {
+	case hasPermission(c, auth.PermProblemsEditAny):
+		query.ProblemStatuses = splitQueryList(c.Query("problem_status"))
+	case hasPermission(c, auth.PermProblemsEditOwn):
+		query.ProblemStatuses = splitQueryList(c.Query("problem_status"))
+		query.EditorUUID = c.GetString("userID")
+	default:
+		query.ProblemStatuses = []string{problems.ProblemStatusPublished}
+	}
 
+	tags, err := problems.NormalizeTags(splitQueryList(c.Query("tags")))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Tags = tags
+
+	if raw := c.Query("limit"); raw != "" {
+		query.Limit, err = strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		query.Cursor, err = problems.DecodeCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	page, err := h.ProblemService.ListProblems(c.GetString("userID"), query)
+	if errors.Is(err, problems.ErrInvalidQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		h.Logger.Error("failed to get problems", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problems"})
 		return
 	}
 
-	c.JSON(http.StatusOK, problems)
+	c.JSON(http.StatusOK, page)
+}
+
+// GetTagsHandler returns all known problem tags
+func (h *Handlers) GetTagsHandler(c *gin.Context) {
+	tags, err := h.ProblemService.ProblemRepo.GetAllTags()
+	if err != nil {
+		h.Logger.Error("failed to get tags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func splitQueryList(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// GetProblemStatsHandler returns submission statistics and the computed difficulty rating of a problem
+func (h *Handlers) GetProblemStatsHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	stats, err := h.ProblemService.GetStats(problem)
+	if err != nil {
+		h.Logger.Error("failed to get problem stats", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
 }
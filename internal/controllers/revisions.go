@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"diplom/internal/problems"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UpdateProblemHandler edits a problem by recording a new revision
+func (h *Handlers) UpdateProblemHandler(c *gin.Context) {
+	problemUUID := c.Param("uuid")
+	if problemUUID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "problem UUID is required"})
+		return
+	}
+
+	var req problems.UpdateProblemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.Logger.Error("failed to bind problem request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	ioSettings, err := req.IOSettings.Normalize()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.IOSettings = ioSettings
+
+	h.saveProblemRevision(c, problemUUID, req)
+}
+
+// GetProblemRevisionsHandler lists all revisions of a problem
+func (h *Handlers) GetProblemRevisionsHandler(c *gin.Context) {
+	revisions, err := h.ProblemService.ProblemRepo.GetProblemRevisions(c.Param("uuid"))
+	if err != nil {
+		h.Logger.Error("failed to get problem revisions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem revisions"})
+		return
+	}
+	if len(revisions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetProblemRevisionHandler returns a single revision of a problem
+func (h *Handlers) GetProblemRevisionHandler(c *gin.Context) {
+	revision, ok := h.getRevision(c, c.Param("revision"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffProblemRevisionsHandler compares two revisions given by the from and to query parameters
+func (h *Handlers) DiffProblemRevisionsHandler(c *gin.Context) {
+	from, ok := h.getRevision(c, c.Query("from"))
+	if !ok {
+		return
+	}
+	to, ok := h.getRevision(c, c.Query("to"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, problems.DiffRevisions(from, to))
+}
+
+// RestoreProblemRevisionHandler makes an old revision current again by copying it into a new revision
+func (h *Handlers) RestoreProblemRevisionHandler(c *gin.Context) {
+	revision, ok := h.getRevision(c, c.Param("revision"))
+	if !ok {
+		return
+	}
+
+	h.saveProblemRevision(c, revision.ProblemUUID, problems.UpdateProblemRequest{
+		CreateProblemRequest: problems.CreateProblemRequest{
+			Name:        revision.Name,
+			Difficulty:  revision.Difficulty,
+			Description: revision.Description,
+			IOSettings:  revision.IOSettings,
+		},
+		Comment: fmt.Sprintf("restored from revision %d", revision.Revision),
+	})
+}
+
+func (h *Handlers) saveProblemRevision(c *gin.Context, problemUUID string, req problems.UpdateProblemRequest) {
+	if err := req.RenderDescription(); err != nil {
+		h.Logger.Error("failed to render description", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to render description"})
+		return
+	}
+
+	revision, err := h.ProblemService.ProblemRepo.UpdateProblem(problemUUID, c.GetString("userID"), req)
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to update problem", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update problem"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "problem updated successfully", "uuid": problemUUID, "revision": revision})
+}
+
+func (h *Handlers) getRevision(c *gin.Context, raw string) (problems.ProblemRevision, bool) {
+	number, err := strconv.Atoi(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision number"})
+		return problems.ProblemRevision{}, false
+	}
+
+	revision, err := h.ProblemService.ProblemRepo.GetProblemRevision(c.Param("uuid"), number)
+	if errors.Is(err, problems.ErrRevisionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("revision %d not found", number)})
+		return problems.ProblemRevision{}, false
+	} else if err != nil {
+		h.Logger.Error("failed to get problem revision", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem revision"})
+		return problems.ProblemRevision{}, false
+	}
+
+	return revision, true
+}
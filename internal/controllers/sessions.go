@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"diplom/internal/auth"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetSessionsHandler returns active sessions of the user, the current one is marked
+func (h *Handlers) GetSessionsHandler(c *gin.Context) {
+	sessions, err := h.AuthService.GetUserSessions(c.GetString("userID"), c.GetString("sessionID"))
+	if err != nil {
+		h.Logger.Error("failed to get sessions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSessionHandler ends one of the user's sessions, e.g. on a lost device
+func (h *Handlers) RevokeSessionHandler(c *gin.Context) {
+	err := h.AuthService.RevokeSession(c.GetString("userID"), c.Param("id"))
+	if errors.Is(err, auth.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to revoke session", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// RevokeUserSessionsHandler ends all sessions of a user, e.g. after a ban.
+// Role changes do not need it: the role is re-read on every request.
+func (h *Handlers) RevokeUserSessionsHandler(c *gin.Context) {
+	userUUID := c.Param("uuid")
+	revoked, err := h.AuthService.RevokeUserSessions(userUUID)
+	if err != nil {
+		h.Logger.Error("failed to revoke user sessions", zap.String("user", userUUID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+
+	h.Logger.Info("user sessions revoked", zap.String("user", userUUID), zap.String("by", c.GetString("userID")))
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked", "revoked": revoked})
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"diplom/internal/problems"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SetStarterCodeHandler sets the editor template of a problem for a language
+func (h *Handlers) SetStarterCodeHandler(c *gin.Context) {
+	language := c.Param("language")
+	if _, err := problems.GetLanguageHandler(language); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req problems.SetStarterCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	problemUUID := c.Param("uuid")
+	if _, err := h.ProblemService.ProblemRepo.GetProblemByUUID(problemUUID, ""); errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		return
+	}
+
+	if err := h.ProblemService.ProblemRepo.SetStarterCode(problemUUID, language, req.Code); err != nil {
+		h.Logger.Error("failed to set starter code", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set starter code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "starter code saved successfully"})
+}
+
+// DeleteStarterCodeHandler restores the default template of the language
+func (h *Handlers) DeleteStarterCodeHandler(c *gin.Context) {
+	err := h.ProblemService.ProblemRepo.DeleteStarterCode(c.Param("uuid"), c.Param("language"))
+	if errors.Is(err, problems.ErrStarterCodeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "starter code not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete starter code", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete starter code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "starter code deleted successfully"})
+}
+
+// SaveDraftHandler autosaves the user's code for a problem and language
+func (h *Handlers) SaveDraftHandler(c *gin.Context) {
+	problem, ok := h.getVisibleProblem(c, c.Param("uuid"))
+	if !ok {
+		return
+	}
+
+	var req problems.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	updatedAt, err := h.ProblemService.SaveDraft(c.GetString("userID"), problem.UUID, c.Param("language"), req.Code)
+	switch {
+	case errors.Is(err, problems.ErrUnsupportedLanguage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, problems.ErrDraftTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case err != nil:
+		h.Logger.Error("failed to save draft", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save draft"})
+	default:
+		c.JSON(http.StatusOK, gin.H{"updated_at": updatedAt})
+	}
+}
+
+// DeleteDraftHandler discards the user's draft, e.g. to reset the editor to the starter code
+func (h *Handlers) DeleteDraftHandler(c *gin.Context) {
+	err := h.ProblemService.ProblemRepo.DeleteDraft(c.GetString("userID"), c.Param("uuid"), c.Param("language"))
+	if errors.Is(err, problems.ErrDraftNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "draft not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete draft", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete draft"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "draft deleted successfully"})
+}
@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"diplom/internal/teams"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// teamID parses the team ID from the path. On failure the error response is already written.
+func teamID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid team ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// GetTeamsHandler returns the user's teams and invitations waiting for an answer
+func (h *Handlers) GetTeamsHandler(c *gin.Context) {
+	userTeams, invitations, err := h.TeamService.GetUserTeams(c.GetString("userID"))
+	if h.teamError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"teams": userTeams, "invitations": invitations})
+}
+
+// CreateTeamHandler creates a team with the user as its captain
+func (h *Handlers) CreateTeamHandler(c *gin.Context) {
+	var req teams.CreateTeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	team, err := h.TeamService.CreateTeam(c.GetString("userID"), req)
+	if h.teamError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *Handlers) GetTeamHandler(c *gin.Context) {
+	id, ok := teamID(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.TeamService.GetTeam(id, c.GetString("userID"))
+	if h.teamError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// DeleteTeamHandler deletes a team on behalf of its captain
+func (h *Handlers) DeleteTeamHandler(c *gin.Context) {
+	id, ok := teamID(c)
+	if !ok {
+		return
+	}
+
+	if h.teamError(c, h.TeamService.DeleteTeam(id, c.GetString("userID"))) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "team deleted successfully"})
+}
+
+// InviteTeamMemberHandler invites a user to the team by username
+func (h *Handlers) InviteTeamMemberHandler(c *gin.Context) {
+	id, ok := teamID(c)
+	if !ok {
+		return
+	}
+
+	var req teams.InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	invitation, err := h.TeamService.Invite(id, c.GetString("userID"), req)
+	if h.teamError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, invitation)
+}
+
+// RemoveTeamMemberHandler removes a member from the team or lets the user leave it
+func (h *Handlers) RemoveTeamMemberHandler(c *gin.Context) {
+	id, ok := teamID(c)
+	if !ok {
+		return
+	}
+	memberUUID := c.Param("uuid")
+	if _, err := uuid.Parse(memberUUID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user UUID"})
+		return
+	}
+
+	if h.teamError(c, h.TeamService.RemoveMember(id, c.GetString("userID"), memberUUID)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member removed from the team"})
+}
+
+// AcceptInvitationHandler adds the user to the team they are invited to
+func (h *Handlers) AcceptInvitationHandler(c *gin.Context) {
+	h.respondInvitation(c, true)
+}
+
+// DeclineInvitationHandler declines an invitation; the captain may withdraw it this way
+func (h *Handlers) DeclineInvitationHandler(c *gin.Context) {
+	h.respondInvitation(c, false)
+}
+
+func (h *Handlers) respondInvitation(c *gin.Context, accept bool) {
+	invitationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation ID format"})
+		return
+	}
+
+	if h.teamError(c, h.TeamService.RespondInvitation(invitationID, c.GetString("userID"), accept)) {
+		return
+	}
+
+	message := "invitation declined"
+	if accept {
+		message = "invitation accepted"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// teamError writes the response for a failed team operation and reports whether there was an error
+func (h *Handlers) teamError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, teams.ErrTeamNotFound) || errors.Is(err, teams.ErrUserNotFound) ||
+		errors.Is(err, teams.ErrInvitationNotFound) || errors.Is(err, teams.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, teams.ErrNotCaptain):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, teams.ErrInvalidTeamName):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, teams.ErrTeamNameTaken) || errors.Is(err, teams.ErrTeamFull) ||
+		errors.Is(err, teams.ErrAlreadyMember) || errors.Is(err, teams.ErrAlreadyInvited) ||
+		errors.Is(err, teams.ErrCaptainCannotLeave):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("team operation failed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"diplom/internal/problems"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// preferredLocales reads the statement locale from ?lang= and the Accept-Language header
+func preferredLocales(c *gin.Context) []string {
+	return problems.PreferredLocales(c.Query("lang"), c.GetHeader("Accept-Language"))
+}
+
+// GetTranslationsHandler returns all translations of a problem with their Markdown sources
+func (h *Handlers) GetTranslationsHandler(c *gin.Context) {
+	problemUUID := c.Param("uuid")
+	if _, err := h.ProblemService.ProblemRepo.GetProblemByUUID(problemUUID, ""); errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to get problem", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get problem"})
+		return
+	}
+
+	translations, err := h.ProblemService.ProblemRepo.GetProblemTranslations(problemUUID)
+	if err != nil {
+		h.Logger.Error("failed to get translations", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get translations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"default_locale": problems.DefaultLocale(),
+		"translations":   translations,
+	})
+}
+
+// SetTranslationHandler creates or replaces the statement of a problem in a locale
+func (h *Handlers) SetTranslationHandler(c *gin.Context) {
+	var req problems.SetTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request payload"})
+		return
+	}
+
+	translation, err := h.ProblemService.SetTranslation(c.Param("uuid"), c.Param("locale"), req)
+	if errors.Is(err, problems.ErrProblemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "problem not found"})
+		return
+	} else if errors.Is(err, problems.ErrInvalidLocale) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to save translation", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save translation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, translation)
+}
+
+func (h *Handlers) DeleteTranslationHandler(c *gin.Context) {
+	locale, err := problems.NormalizeLocale(c.Param("locale"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.ProblemService.ProblemRepo.DeleteProblemTranslation(c.Param("uuid"), locale)
+	if errors.Is(err, problems.ErrTranslationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "translation not found"})
+		return
+	} else if err != nil {
+		h.Logger.Error("failed to delete translation", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete translation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "translation deleted successfully"})
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"diplom/internal/problems"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,6 +26,13 @@ func (h *Handlers) ProfileHandler(c *gin.Context) {
 		return
 	}
 
+	// Прогресс по коллекциям задач
+	collections, err := h.ProblemService.GetCollections(userID)
+	if err != nil {
+		h.Logger.Error("failed to get collections", zap.Error(err))
+		collections = []problems.Collection{}
+	}
+
 	// Получаем список всех задач для пользователя
 	problems, err := h.ProblemService.ProblemRepo.GetAllProblems(userID)
 	if err != nil {
@@ -35,8 +43,12 @@ func (h *Handlers) ProfileHandler(c *gin.Context) {
 	easyCount := 0
 	mediumCount := 0
 	hardCount := 0
+	totalScore := 0.0
+	maxTotalScore := 0.0
 
 	for _, p := range problems {
+		totalScore += p.BestScore
+		maxTotalScore += p.MaxScore
 		if p.Solved {
 			switch p.Difficulty {
 			case "easy":
@@ -78,6 +90,9 @@ func (h *Handlers) ProfileHandler(c *gin.Context) {
 		"streak":        currentStreak,
 		"longestStreak": longestStreak,
 		"successRate":   successRate,
+		"totalScore":    totalScore,
+		"maxTotalScore": maxTotalScore,
+		"collections":   collections,
 	}
 
 	c.JSON(http.StatusOK, response)
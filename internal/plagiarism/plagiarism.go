@@ -0,0 +1,182 @@
+package plagiarism
+
+import (
+	"context"
+	"diplom/config"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Submission is an accepted solution taking part in the comparison
+type Submission struct {
+	SolutionID int    `json:"solution_id"`
+	UserUUID   string `json:"user_uuid"`
+	Username   string `json:"username"`
+	Language   string `json:"language"`
+	Code       string `json:"-"`
+}
+
+// PairSimilarity is the comparison result for two submissions of different users
+type PairSimilarity struct {
+	ProblemUUID string     `json:"problem_uuid"`
+	Language    string     `json:"language"`
+	First       Submission `json:"first"`
+	Second      Submission `json:"second"`
+	Similarity  float64    `json:"similarity"`
+	Fragments   []Fragment `json:"fragments"`
+	ComputedAt  time.Time  `json:"computed_at"`
+}
+
+// Repository defines the data access interface for the analyzer
+type Repository interface {
+	GetProblemUUIDsWithAcceptedSolutions() ([]string, error)
+	GetLatestAcceptedSubmissions(problemUUID string) ([]Submission, error)
+	ReplaceSimilarities(problemUUID string, pairs []PairSimilarity) error
+	GetSimilarities(problemUUID string, minSimilarity float64) ([]PairSimilarity, error)
+}
+
+// Service analyzes accepted submissions in the background
+type Service struct {
+	Repo   Repository
+	Logger *zap.Logger
+	config config.PlagiarismConfig
+
+	mu      sync.Mutex
+	pending map[string]struct{}
+	wake    chan struct{}
+}
+
+// NewService creates an analyzer with the given configuration
+func NewService(repo Repository, logger *zap.Logger, cfg config.PlagiarismConfig) *Service {
+	if cfg.KGram <= 0 {
+		cfg.KGram = 5
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 4
+	}
+	if cfg.IntervalMinutes <= 0 {
+		cfg.IntervalMinutes = 60
+	}
+	return &Service{
+		Repo:    repo,
+		Logger:  logger.Named("plagiarism"),
+		config:  cfg,
+		pending: make(map[string]struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Threshold returns the similarity above which a pair is considered suspicious
+func (s *Service) Threshold() float64 {
+	return s.config.Threshold
+}
+
+// Enqueue schedules re-analysis of a problem, e.g. after a new accepted submission
+func (s *Service) Enqueue(problemUUID string) {
+	s.mu.Lock()
+	s.pending[problemUUID] = struct{}{}
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes queued problems and periodically re-analyzes all of them
+// until the context is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(s.config.IntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+
+	s.enqueueAll()
+	for {
+		s.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+			s.enqueueAll()
+		}
+	}
+}
+
+func (s *Service) enqueueAll() {
+	problemUUIDs, err := s.Repo.GetProblemUUIDsWithAcceptedSolutions()
+	if err != nil {
+		s.Logger.Error("failed to list problems for analysis", zap.Error(err))
+		return
+	}
+	s.mu.Lock()
+	for _, uuid := range problemUUIDs {
+		s.pending[uuid] = struct{}{}
+	}
+	s.mu.Unlock()
+}
+
+func (s *Service) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		s.mu.Lock()
+		var problemUUID string
+		for uuid := range s.pending {
+			problemUUID = uuid
+			break
+		}
+		delete(s.pending, problemUUID)
+		s.mu.Unlock()
+
+		if problemUUID == "" {
+			return
+		}
+		if err := s.AnalyzeProblem(problemUUID); err != nil {
+			s.Logger.Error("failed to analyze problem", zap.String("problem_uuid", problemUUID), zap.Error(err))
+		}
+	}
+}
+
+// AnalyzeProblem compares the latest accepted submissions of every pair of
+// users per language and stores the scores
+func (s *Service) AnalyzeProblem(problemUUID string) error {
+	submissions, err := s.Repo.GetLatestAcceptedSubmissions(problemUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get submissions: %w", err)
+	}
+
+	byLanguage := make(map[string][]Submission)
+	for _, sub := range submissions {
+		byLanguage[sub.Language] = append(byLanguage[sub.Language], sub)
+	}
+
+	now := time.Now()
+	var pairs []PairSimilarity
+	for language, subs := range byLanguage {
+		docs := make([]*Document, len(subs))
+		for i, sub := range subs {
+			docs[i] = NewDocument(sub.Code, language, s.config.KGram, s.config.Window)
+		}
+		for i := 0; i < len(subs); i++ {
+			for j := i + 1; j < len(subs); j++ {
+				if subs[i].UserUUID == subs[j].UserUUID {
+					continue
+				}
+				similarity, fragments := Compare(docs[i], docs[j], s.config.KGram)
+				pairs = append(pairs, PairSimilarity{
+					ProblemUUID: problemUUID,
+					Language:    language,
+					First:       subs[i],
+					Second:      subs[j],
+					Similarity:  similarity,
+					Fragments:   fragments,
+					ComputedAt:  now,
+				})
+			}
+		}
+	}
+
+	s.Logger.Debug("problem analyzed", zap.String("problem_uuid", problemUUID), zap.Int("pairs", len(pairs)))
+	return s.Repo.ReplaceSimilarities(problemUUID, pairs)
+}
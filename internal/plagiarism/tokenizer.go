@@ -0,0 +1,147 @@
+package plagiarism
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a normalized lexeme together with the source line it came from
+type Token struct {
+	Text string
+	Line int
+}
+
+// Normalized token texts; identifiers and literals are collapsed so that
+// renaming variables or changing constants does not hide copied code
+const (
+	tokenIdentifier = "$id"
+	tokenNumber     = "$num"
+	tokenString     = "$str"
+)
+
+var keywords = map[string]map[string]struct{}{
+	"python": setOf("and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del",
+		"elif", "else", "except", "False", "finally", "for", "from", "global", "if", "import", "in", "is",
+		"lambda", "None", "nonlocal", "not", "or", "pass", "raise", "return", "True", "try", "while",
+		"with", "yield", "print", "input", "range", "len", "int", "str", "list", "dict", "set", "map"),
+	"cpp": setOf("auto", "bool", "break", "case", "char", "class", "const", "continue", "default", "delete",
+		"do", "double", "else", "enum", "false", "float", "for", "if", "include", "int", "long", "namespace",
+		"new", "nullptr", "private", "public", "return", "short", "signed", "sizeof", "static", "std",
+		"struct", "switch", "template", "this", "true", "typedef", "unsigned", "using", "vector", "void",
+		"while", "cin", "cout", "endl", "string", "map", "set", "pair"),
+	"java": setOf("abstract", "boolean", "break", "byte", "case", "catch", "char", "class", "continue",
+		"default", "do", "double", "else", "extends", "false", "final", "finally", "float", "for", "if",
+		"implements", "import", "instanceof", "int", "interface", "long", "new", "null", "private",
+		"protected", "public", "return", "short", "static", "super", "switch", "this", "throw", "throws",
+		"true", "try", "void", "while", "String", "System", "Scanner", "List", "Map", "Set"),
+}
+
+func setOf(words ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		set[w] = struct{}{}
+	}
+	return set
+}
+
+// multiCharOperators are matched greedily before single-character punctuation
+var multiCharOperators = []string{
+	"<<=", ">>=", "...", "**=", "//=", "->", "::", "++", "--", "<<", ">>", "<=", ">=", "==", "!=",
+	"&&", "||", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "**", "//",
+}
+
+// Tokenize splits source code into a normalized token stream. Comments and
+// whitespace are dropped, identifiers that are not language keywords become
+// a single placeholder, as do numeric and string literals.
+func Tokenize(code, language string) []Token {
+	kw := keywords[language]
+	lineComment := "//"
+	if language == "python" {
+		lineComment = "#"
+	}
+
+	var tokens []Token
+	line := 1
+	for i := 0; i < len(code); {
+		c := code[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(code[i:], lineComment):
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+		case language != "python" && strings.HasPrefix(code[i:], "/*"):
+			end := strings.Index(code[i+2:], "*/")
+			if end < 0 {
+				end = len(code) - i - 2
+			}
+			line += strings.Count(code[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"' || c == '\'':
+			start := i
+			quote := c
+			// Python triple-quoted strings (often used as comments too)
+			if language == "python" && strings.HasPrefix(code[i:], strings.Repeat(string(quote), 3)) {
+				delim := strings.Repeat(string(quote), 3)
+				end := strings.Index(code[i+3:], delim)
+				if end < 0 {
+					end = len(code) - i - 3
+				}
+				i += end + 6
+			} else {
+				i++
+				for i < len(code) && code[i] != quote && code[i] != '\n' {
+					if code[i] == '\\' {
+						i++
+					}
+					i++
+				}
+				i++
+			}
+			if i > len(code) {
+				i = len(code)
+			}
+			tokens = append(tokens, Token{Text: tokenString, Line: line})
+			line += strings.Count(code[start:i], "\n")
+		case isIdentStart(c):
+			start := i
+			for i < len(code) && isIdentPart(code[i]) {
+				i++
+			}
+			word := code[start:i]
+			if _, ok := kw[word]; ok {
+				tokens = append(tokens, Token{Text: word, Line: line})
+			} else {
+				tokens = append(tokens, Token{Text: tokenIdentifier, Line: line})
+			}
+		case c >= '0' && c <= '9':
+			for i < len(code) && (isIdentPart(code[i]) || code[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, Token{Text: tokenNumber, Line: line})
+		default:
+			op := string(c)
+			for _, candidate := range multiCharOperators {
+				if strings.HasPrefix(code[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			i += len(op)
+			tokens = append(tokens, Token{Text: op, Line: line})
+		}
+	}
+	return tokens
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c))
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
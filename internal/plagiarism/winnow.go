@@ -0,0 +1,164 @@
+package plagiarism
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// Fingerprint is a selected k-gram hash and the token position it starts at
+type Fingerprint struct {
+	Hash     uint64
+	Position int
+}
+
+// Winnow selects fingerprints from the token stream as described in
+// "Winnowing: Local Algorithms for Document Fingerprinting" (Schleimer et al.),
+// the algorithm behind MOSS: hash every k-gram and keep the minimum hash of
+// each window of w consecutive hashes (the rightmost one on ties).
+func Winnow(tokens []Token, k, w int) []Fingerprint {
+	if k <= 0 || w <= 0 || len(tokens) < k {
+		return nil
+	}
+
+	hashes := make([]uint64, len(tokens)-k+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, t := range tokens[i : i+k] {
+			h.Write([]byte(t.Text))
+			h.Write([]byte{0})
+		}
+		hashes[i] = h.Sum64()
+	}
+
+	// Short documents still get one fingerprint per window-sized chunk
+	if len(hashes) < w {
+		w = len(hashes)
+	}
+
+	var fingerprints []Fingerprint
+	last := -1
+	for start := 0; start+w <= len(hashes); start++ {
+		minPos := start
+		for i := start; i < start+w; i++ {
+			if hashes[i] <= hashes[minPos] {
+				minPos = i
+			}
+		}
+		if minPos != last {
+			fingerprints = append(fingerprints, Fingerprint{Hash: hashes[minPos], Position: minPos})
+			last = minPos
+		}
+	}
+	return fingerprints
+}
+
+// Document is a tokenized and fingerprinted submission
+type Document struct {
+	Source       string
+	Tokens       []Token
+	Fingerprints []Fingerprint
+	index        map[uint64]int
+}
+
+// NewDocument tokenizes and fingerprints the source code
+func NewDocument(code, language string, k, w int) *Document {
+	tokens := Tokenize(code, language)
+	doc := &Document{
+		Source:       code,
+		Tokens:       tokens,
+		Fingerprints: Winnow(tokens, k, w),
+		index:        make(map[uint64]int),
+	}
+	for _, fp := range doc.Fingerprints {
+		if _, ok := doc.index[fp.Hash]; !ok {
+			doc.index[fp.Hash] = fp.Position
+		}
+	}
+	return doc
+}
+
+// Fragment is a pair of line ranges (1-based, inclusive) with matching code
+type Fragment struct {
+	FirstStartLine  int    `json:"first_start_line"`
+	FirstEndLine    int    `json:"first_end_line"`
+	SecondStartLine int    `json:"second_start_line"`
+	SecondEndLine   int    `json:"second_end_line"`
+	FirstCode       string `json:"first_code"`
+	SecondCode      string `json:"second_code"`
+}
+
+const maxFragments = 20
+
+// Compare returns the share of fingerprints of the smaller document that
+// also occur in the other one (0..1) and the matched code fragments
+func Compare(a, b *Document, k int) (float64, []Fragment) {
+	if len(a.index) == 0 || len(b.index) == 0 {
+		return 0, nil
+	}
+
+	type match struct{ posA, posB int }
+	var matches []match
+	for hash, posA := range a.index {
+		if posB, ok := b.index[hash]; ok {
+			matches = append(matches, match{posA, posB})
+		}
+	}
+
+	smaller := len(a.index)
+	if len(b.index) < smaller {
+		smaller = len(b.index)
+	}
+	score := float64(len(matches)) / float64(smaller)
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].posA < matches[j].posA })
+
+	// Merge k-grams that overlap in both documents into contiguous fragments
+	var fragments []Fragment
+	for _, m := range matches {
+		aStart, aEnd := a.Tokens[m.posA].Line, a.Tokens[m.posA+k-1].Line
+		bStart, bEnd := b.Tokens[m.posB].Line, b.Tokens[m.posB+k-1].Line
+		if n := len(fragments); n > 0 {
+			last := &fragments[n-1]
+			if aStart <= last.FirstEndLine+1 && bStart >= last.SecondStartLine && bStart <= last.SecondEndLine+1 {
+				last.FirstEndLine = max(last.FirstEndLine, aEnd)
+				last.SecondEndLine = max(last.SecondEndLine, bEnd)
+				continue
+			}
+		}
+		fragments = append(fragments, Fragment{
+			FirstStartLine:  aStart,
+			FirstEndLine:    aEnd,
+			SecondStartLine: bStart,
+			SecondEndLine:   bEnd,
+		})
+	}
+
+	// Longest fragments are the most telling ones
+	sort.SliceStable(fragments, func(i, j int) bool {
+		return fragments[i].FirstEndLine-fragments[i].FirstStartLine > fragments[j].FirstEndLine-fragments[j].FirstStartLine
+	})
+	if len(fragments) > maxFragments {
+		fragments = fragments[:maxFragments]
+	}
+	for i := range fragments {
+		fragments[i].FirstCode = sourceLines(a.Source, fragments[i].FirstStartLine, fragments[i].FirstEndLine)
+		fragments[i].SecondCode = sourceLines(b.Source, fragments[i].SecondStartLine, fragments[i].SecondEndLine)
+	}
+
+	return score, fragments
+}
+
+func sourceLines(source string, from, to int) string {
+	lines := strings.Split(source, "\n")
+	if from < 1 {
+		from = 1
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	if from > to {
+		return ""
+	}
+	return strings.Join(lines[from-1:to], "\n")
+}
@@ -0,0 +1,147 @@
+package problems
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	// MaxAttachmentSize limits a single attachment
+	MaxAttachmentSize = 10 << 20
+	// MaxProblemAttachmentsSize limits all attachments of a problem together
+	MaxProblemAttachmentsSize = 100 << 20
+)
+
+var (
+	ErrAttachmentNotFound      = errors.New("attachment not found")
+	ErrInvalidAttachmentName   = errors.New("attachment name may contain only letters, digits, '.', '_' and '-'")
+	ErrAttachmentTooLarge      = fmt.Errorf("attachment exceeds %d MB", MaxAttachmentSize>>20)
+	ErrAttachmentQuotaExceeded = fmt.Errorf("attachments of a problem exceed %d MB", MaxProblemAttachmentsSize>>20)
+	ErrAttachmentType          = errors.New("unsupported attachment type")
+)
+
+var attachmentNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// Типы определяются по содержимому, а не по расширению. SVG не допускается:
+// он может содержать скрипты.
+var attachmentTypes = map[string]bool{
+	"image/png":                 true,
+	"image/jpeg":                true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"application/zip":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+// Attachment is a file of a problem referenced from the statement by its URL,
+// which stays the same when the file is replaced
+type Attachment struct {
+	ID          int       `json:"id"`
+	ProblemUUID string    `json:"problem_uuid"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Key         string    `json:"-"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentURL returns the stable URL of the attachment for use in statements
+func AttachmentURL(problemUUID, name string) string {
+	return fmt.Sprintf("/api/problem/%s/attachments/%s", problemUUID, name)
+}
+
+// IsInlineType reports whether the browser may display the attachment instead of downloading it
+func IsInlineType(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// UploadAttachment validates and stores the file, replacing an attachment with the same name
+func (s *ProblemService) UploadAttachment(ctx context.Context, problemUUID, name string, r io.Reader, size int64) (Attachment, error) {
+	if !attachmentNamePattern.MatchString(name) {
+		return Attachment{}, ErrInvalidAttachmentName
+	}
+	if size > MaxAttachmentSize {
+		return Attachment{}, ErrAttachmentTooLarge
+	}
+	if _, err := s.ProblemRepo.GetProblemByUUID(problemUUID, ""); err != nil {
+		return Attachment{}, err
+	}
+
+	used, err := s.ProblemRepo.GetAttachmentsSize(problemUUID, name)
+	if err != nil {
+		return Attachment{}, err
+	}
+	if used+size > MaxProblemAttachmentsSize {
+		return Attachment{}, ErrAttachmentQuotaExceeded
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Attachment{}, err
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !attachmentTypes[contentType] {
+		return Attachment{}, fmt.Errorf("%w: %s", ErrAttachmentType, contentType)
+	}
+
+	key, stored, err := s.TestData.Store(ctx, io.LimitReader(io.MultiReader(bytes.NewReader(head), r), MaxAttachmentSize+1))
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	if stored > MaxAttachmentSize {
+		return Attachment{}, ErrAttachmentTooLarge
+	}
+
+	attachment := Attachment{
+		ProblemUUID: problemUUID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        stored,
+		Key:         key,
+		URL:         AttachmentURL(problemUUID, name),
+	}
+	attachment.ID, attachment.CreatedAt, err = s.ProblemRepo.SaveAttachment(attachment)
+	if err != nil {
+		return Attachment{}, err
+	}
+	return attachment, nil
+}
+
+// GetAttachments lists the attachments of the problem
+func (s *ProblemService) GetAttachments(problemUUID string) ([]Attachment, error) {
+	attachments, err := s.ProblemRepo.GetAttachments(problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		attachments[i].URL = AttachmentURL(problemUUID, attachments[i].Name)
+	}
+	return attachments, nil
+}
+
+// OpenAttachment returns the attachment and a reader for its content
+func (s *ProblemService) OpenAttachment(ctx context.Context, problemUUID, name string) (Attachment, io.ReadCloser, error) {
+	attachment, err := s.ProblemRepo.GetAttachment(problemUUID, name)
+	if err != nil {
+		return Attachment{}, nil, err
+	}
+	data, err := s.TestData.Open(ctx, attachment.Key)
+	if err != nil {
+		return Attachment{}, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return attachment, data, nil
+}
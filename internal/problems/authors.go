@@ -0,0 +1,40 @@
+package problems
+
+import "errors"
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrCoAuthorNotFound = errors.New("co-author not found")
+	ErrCoAuthorIsOwner  = errors.New("the owner cannot be a co-author")
+)
+
+// CoAuthor is a user allowed to edit the problem besides its owner
+type CoAuthor struct {
+	UUID     string `json:"uuid"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// AddCoAuthorRequest adds a co-author by username
+type AddCoAuthorRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// AddCoAuthor grants the user edit access to the problem
+func (s *ProblemService) AddCoAuthor(problemUUID, username string) (CoAuthor, error) {
+	problem, err := s.ProblemRepo.GetProblemByUUID(problemUUID, "")
+	if err != nil {
+		return CoAuthor{}, err
+	}
+	coAuthor, err := s.ProblemRepo.GetUserByUsername(username)
+	if err != nil {
+		return CoAuthor{}, err
+	}
+	if coAuthor.UUID == problem.OwnerUUID {
+		return CoAuthor{}, ErrCoAuthorIsOwner
+	}
+	if err := s.ProblemRepo.AddCoAuthor(problemUUID, coAuthor.UUID); err != nil {
+		return CoAuthor{}, err
+	}
+	return coAuthor, nil
+}
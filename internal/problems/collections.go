@@ -0,0 +1,185 @@
+package problems
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+var (
+	ErrCollectionNotFound     = errors.New("collection not found")
+	ErrPrerequisiteNotFound   = errors.New("prerequisite collection not found")
+	ErrPrerequisiteCycle      = errors.New("prerequisites must not form a cycle")
+	ErrDuplicateCollectionRef = errors.New("collection lists the same problem or prerequisite twice")
+)
+
+// Collection is an ordered, admin-curated list of problems, e.g. a weekly topic
+// of a course. Progress fields are computed for the requesting user over
+// published problems; a collection is locked until all its prerequisites are completed.
+type Collection struct {
+	ID            int                 `json:"id"`
+	Title         string              `json:"title"`
+	Description   string              `json:"description"`
+	Position      int                 `json:"position"`
+	Prerequisites []int               `json:"prerequisites"`
+	Total         int                 `json:"total"`
+	Solved        int                 `json:"solved"`
+	Progress      float64             `json:"progress"` // percent of solved problems
+	Completed     bool                `json:"completed"`
+	Locked        bool                `json:"locked"`
+	Problems      []CollectionProblem `json:"problems,omitempty"`
+}
+
+// CollectionProblem is a problem of a collection with the user's result
+type CollectionProblem struct {
+	UUID       string `json:"uuid"`
+	Name       string `json:"name"`
+	Difficulty string `json:"difficulty"`
+	Status     string `json:"status"`
+	Solved     bool   `json:"solved"`
+}
+
+// CollectionRequest creates or replaces a collection. Problems are listed in order.
+type CollectionRequest struct {
+	Title         string   `json:"title" binding:"required"`
+	Description   string   `json:"description"`
+	Position      int      `json:"position"`
+	Problems      []string `json:"problems"`
+	Prerequisites []int    `json:"prerequisites"`
+}
+
+// GetCollections returns all collections with the user's progress
+func (s *ProblemService) GetCollections(userID string) ([]Collection, error) {
+	collections, err := s.ProblemRepo.GetCollections(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[int]bool, len(collections))
+	for i := range collections {
+		c := &collections[i]
+		if c.Total > 0 {
+			c.Progress = math.Round(float64(c.Solved)/float64(c.Total)*10000) / 100
+		}
+		c.Completed = c.Total > 0 && c.Solved == c.Total
+		completed[c.ID] = c.Completed
+	}
+	for i := range collections {
+		for _, id := range collections[i].Prerequisites {
+			if !completed[id] {
+				collections[i].Locked = true
+				break
+			}
+		}
+	}
+	return collections, nil
+}
+
+// GetCollection returns a collection with its problems. Unpublished problems
+// are listed only when includeUnpublished is set.
+func (s *ProblemService) GetCollection(userID string, id int, includeUnpublished bool) (Collection, error) {
+	collections, err := s.GetCollections(userID)
+	if err != nil {
+		return Collection{}, err
+	}
+	i := slices.IndexFunc(collections, func(c Collection) bool { return c.ID == id })
+	if i < 0 {
+		return Collection{}, ErrCollectionNotFound
+	}
+
+	collection := collections[i]
+	collection.Problems, err = s.ProblemRepo.GetCollectionProblems(id, userID, includeUnpublished)
+	if err != nil {
+		return Collection{}, err
+	}
+	return collection, nil
+}
+
+// CreateCollection validates and stores a new collection
+func (s *ProblemService) CreateCollection(req CollectionRequest) (int, error) {
+	if err := s.validateCollection(0, req); err != nil {
+		return 0, err
+	}
+	return s.ProblemRepo.CreateCollection(req)
+}
+
+// UpdateCollection replaces the collection, its problems and prerequisites
+func (s *ProblemService) UpdateCollection(id int, req CollectionRequest) error {
+	if err := s.validateCollection(id, req); err != nil {
+		return err
+	}
+	return s.ProblemRepo.UpdateCollection(id, req)
+}
+
+// validateCollection checks that problems and prerequisites exist and that
+// prerequisites stay acyclic. id is 0 for a new collection.
+func (s *ProblemService) validateCollection(id int, req CollectionRequest) error {
+	if hasDuplicates(req.Problems) || hasDuplicates(req.Prerequisites) {
+		return ErrDuplicateCollectionRef
+	}
+	for _, problemUUID := range req.Problems {
+		if _, err := s.ProblemRepo.GetProblemByUUID(problemUUID, ""); err != nil {
+			return fmt.Errorf("problem %s: %w", problemUUID, err)
+		}
+	}
+
+	collections, err := s.ProblemRepo.GetCollections("")
+	if err != nil {
+		return err
+	}
+	graph := make(map[int][]int, len(collections)+1)
+	for _, c := range collections {
+		graph[c.ID] = c.Prerequisites
+	}
+	if id != 0 {
+		if _, ok := graph[id]; !ok {
+			return ErrCollectionNotFound
+		}
+	}
+	for _, prereq := range req.Prerequisites {
+		if _, ok := graph[prereq]; !ok || prereq == id {
+			return ErrPrerequisiteNotFound
+		}
+	}
+	if id == 0 {
+		// У новой коллекции нет входящих рёбер, цикл невозможен
+		return nil
+	}
+
+	graph[id] = req.Prerequisites
+	if reachable(graph, req.Prerequisites, id) {
+		return ErrPrerequisiteCycle
+	}
+	return nil
+}
+
+// reachable reports whether target can be reached from any of the start nodes
+func reachable(graph map[int][]int, start []int, target int) bool {
+	visited := map[int]bool{}
+	stack := slices.Clone(start)
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node == target {
+			return true
+		}
+		if visited[node] {
+			continue
+		}
+		visited[node] = true
+		stack = append(stack, graph[node]...)
+	}
+	return false
+}
+
+func hasDuplicates[T comparable](items []T) bool {
+	seen := make(map[T]struct{}, len(items))
+	for _, item := range items {
+		if _, ok := seen[item]; ok {
+			return true
+		}
+		seen[item] = struct{}{}
+	}
+	return false
+}
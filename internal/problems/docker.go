@@ -5,6 +5,7 @@ import (
 	"context"
 	"diplom/config"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -24,8 +25,13 @@ type LanguageHandler interface {
 	GetSourceFilename() string
 	GetCompileCommand(filename string) string
 	GetRunCommand(workdir string) []string
+	// GetStarterCode returns the default template for problems without a custom one
+	GetStarterCode(io IOSettings) string
 }
 
+// SupportedLanguages lists languages accepted by GetLanguageHandler
+var SupportedLanguages = []string{"python", "cpp", "java"}
+
 // Python language implementation
 type PythonHandler struct{}
 
@@ -35,6 +41,28 @@ func (h PythonHandler) GetCompileCommand(_ string) string { return "" } // Pytho
 func (h PythonHandler) GetRunCommand(_ string) []string {
 	return []string{"python3", "/workspace/solution.py"}
 }
+func (h PythonHandler) GetStarterCode(io IOSettings) string {
+	if io.Mode == IOModeFile {
+		return fmt.Sprintf(`def main():
+    with open(%[1]q) as fin, open(%[2]q, "w") as fout:
+        data = fin.read().split()
+        # fout.write(...)
+
+
+main()
+`, io.InputFile, io.OutputFile)
+	}
+	return `import sys
+
+
+def main():
+    data = sys.stdin.read().split()
+    # print(...)
+
+
+main()
+`
+}
 
 // C++ language implementation
 type CppHandler struct{}
@@ -45,6 +73,22 @@ func (h CppHandler) GetCompileCommand(filename string) string {
 	return fmt.Sprintf("g++ -O1 --param=ggc-min-expand=20 --param=ggc-min-heapsize=8192 /workspace/%s -o /workspace/solution", filename)
 }
 func (h CppHandler) GetRunCommand(_ string) []string { return []string{"/workspace/solution"} }
+func (h CppHandler) GetStarterCode(io IOSettings) string {
+	redirect := ""
+	if io.Mode == IOModeFile {
+		redirect = fmt.Sprintf("    freopen(%q, \"r\", stdin);\n    freopen(%q, \"w\", stdout);\n", io.InputFile, io.OutputFile)
+	}
+	return `#include <bits/stdc++.h>
+using namespace std;
+
+int main() {
+` + redirect + `    ios::sync_with_stdio(false);
+    cin.tie(nullptr);
+
+    return 0;
+}
+`
+}
 
 // Java language implementation
 type JavaHandler struct{}
@@ -58,6 +102,27 @@ func (h JavaHandler) GetRunCommand(workdir string) []string {
 	return []string{"java", "-cp", workdir, "Solution"}
 }
 
+// Класс обязан называться Solution: так его запускает GetRunCommand
+func (h JavaHandler) GetStarterCode(io IOSettings) string {
+	in, out := "new InputStreamReader(System.in)", "System.out"
+	if io.Mode == IOModeFile {
+		in = fmt.Sprintf("new FileReader(%q)", io.InputFile)
+		out = fmt.Sprintf("new FileWriter(%q)", io.OutputFile)
+	}
+	return fmt.Sprintf(`import java.io.*;
+import java.util.*;
+
+public class Solution {
+    public static void main(String[] args) throws IOException {
+        BufferedReader in = new BufferedReader(%s);
+        PrintWriter out = new PrintWriter(%s);
+
+        out.flush();
+    }
+}
+`, in, out)
+}
+
 // GetLanguageHandler returns the appropriate handler for a language
 func GetLanguageHandler(language string) (LanguageHandler, error) {
 	switch language {
@@ -72,18 +137,79 @@ func GetLanguageHandler(language string) (LanguageHandler, error) {
 	}
 }
 
+// LanguageByExtension returns the language of a source file by its extension
+func LanguageByExtension(ext string) (string, bool) {
+	switch strings.ToLower(ext) {
+	case ".py":
+		return "python", true
+	case ".cpp", ".cc", ".cxx":
+		return "cpp", true
+	case ".java":
+		return "java", true
+	default:
+		return "", false
+	}
+}
+
 const maxContainers = 15
 
+const (
+	// sandboxUser is the unprivileged uid:gid solutions are executed as, so
+	// they cannot touch the pristine build snapshot or outlive cleanup
+	sandboxUser = "65534:65534"
+	// workspaceDir is where sources are compiled and each test is executed
+	workspaceDir = "/workspace"
+	// snapshotDir holds a read-only (for sandboxUser) copy of the compiled artifacts
+	snapshotDir = "/judge"
+)
+
+// killSandboxScript defines kill_sandbox: it kills every process of sandboxUser
+// and fails once none is left. kill -9 -1 is not used as it runs as root and
+// would also kill the keep-alive entrypoint of the container.
+var killSandboxScript = fmt.Sprintf(`
+kill_sandbox() {
+	pids=""
+	for s in /proc/[0-9]*/status; do
+		if grep -q "^Uid:[[:space:]]*%[1]s[[:space:]]" "$s" 2>/dev/null; then
+			p=${s#/proc/}
+			pids="$pids ${p%%/status}"
+		fi
+	done
+	[ -z "$pids" ] && return 1
+	kill -9 $pids 2>/dev/null
+	return 0
+}
+`, strings.Split(sandboxUser, ":")[0])
+
+// resetWorkspaceScript kills every process left by the previous test, waits
+// until none of them remain and restores the workspace from the snapshot
+var resetWorkspaceScript = killSandboxScript + fmt.Sprintf(`
+i=0
+while kill_sandbox; do
+	if [ $i -ge 100 ]; then echo "sandbox processes are still alive" >&2; exit 1; fi
+	sleep 0.02
+	i=$((i+1))
+done
+find %[1]s -mindepth 1 -delete && cp -R %[2]s/. %[1]s/
+`, workspaceDir, snapshotDir)
+
 // DockerClient manages Docker interaction for code execution
 type DockerClient struct {
 	client    *client.Client
 	logger    *zap.Logger
 	config    config.RuntimeConfig
+	testData  *TestData
 	semaphore chan struct{}
 }
 
+// maxReportedOutput limits the actual output returned for a failed test
+const maxReportedOutput = 64 * 1024
+
+// defaultOutputLimitKB is used when the runtime config doesn't set output_limit_kb
+const defaultOutputLimitKB = 16 * 1024
+
 // NewDockerClient creates a new Docker client with the given configuration
-func NewDockerClient(logger *zap.Logger, config config.RuntimeConfig) (*DockerClient, error) {
+func NewDockerClient(logger *zap.Logger, config config.RuntimeConfig, testData *TestData) (*DockerClient, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, err
@@ -92,6 +218,7 @@ func NewDockerClient(logger *zap.Logger, config config.RuntimeConfig) (*DockerCl
 		client:    cli,
 		logger:    logger,
 		config:    config,
+		testData:  testData,
 		semaphore: make(chan struct{}, maxContainers),
 	}, nil
 }
@@ -116,15 +243,18 @@ func (d *DockerClient) CreateContainer(ctx context.Context, code, language strin
 	compileCmd := handler.GetCompileCommand(srcFilename)
 
 	memoryLimit := int64(d.config.MemoryLimitMB)
+	// Init reaps processes killed between tests so they don't linger as zombies
+	useInit := true
 	// Create container with secure configuration
 	resp, err := d.client.ContainerCreate(ctx,
 		&container.Config{
 			Image:      imageName,
 			Entrypoint: []string{"tail", "-f", "/dev/null"},
 			Tty:        false,
-			WorkingDir: "/workspace",
+			WorkingDir: workspaceDir,
 		},
 		&container.HostConfig{
+			Init: &useInit,
 			Resources: container.Resources{
 				Memory:     memoryLimit * 1024 * 1024,
 				MemorySwap: memoryLimit * 1024 * 1024,             // Disable swap
@@ -137,7 +267,8 @@ func (d *DockerClient) CreateContainer(ctx context.Context, code, language strin
 			AutoRemove:     true,
 			NetworkMode:    "none",
 			Tmpfs: map[string]string{
-				"/workspace": "rw,exec,nosuid,size=100m",
+				workspaceDir: "rw,exec,nosuid,size=100m,mode=1777",
+				snapshotDir:  "rw,exec,nosuid,size=100m,mode=0755",
 			},
 			SecurityOpt: []string{
 				"no-new-privileges:true",
@@ -180,9 +311,31 @@ func (d *DockerClient) CreateContainer(ctx context.Context, code, language strin
 		}
 	}
 
+	// Keep a pristine copy of the compiled artifacts to restore before every test
+	_, stderr, err = d.execCommand(ctx, resp.ID, fmt.Sprintf("cp -R %s/. %s/", workspaceDir, snapshotDir))
+	if err != nil {
+		return resp.ID, "", fmt.Errorf("failed to snapshot workspace: %w", err)
+	}
+	if stderr.Len() > 0 {
+		return resp.ID, "", fmt.Errorf("failed to snapshot workspace: %s", stderr.String())
+	}
+
 	return resp.ID, "", nil
 }
 
+// resetWorkspace guarantees that no process from a previous test is alive and
+// that the workspace contains nothing but the compiled artifacts
+func (d *DockerClient) resetWorkspace(ctx context.Context, containerID string) error {
+	_, stderr, err := d.execCommand(ctx, containerID, resetWorkspaceScript)
+	if err != nil {
+		return fmt.Errorf("failed to reset workspace: %w", err)
+	}
+	if stderr.Len() > 0 {
+		return fmt.Errorf("failed to reset workspace: %s", stderr.String())
+	}
+	return nil
+}
+
 // RemoveContainer removes a Docker container
 func (d *DockerClient) RemoveContainer(ctx context.Context, containerID string) error {
 	return d.client.ContainerRemove(ctx, containerID, container.RemoveOptions{
@@ -211,26 +364,36 @@ func (d *DockerClient) execCommand(ctx context.Context, containerID, cmd string)
 	return outBuf, errBuf, err
 }
 
-// ExecuteCode runs test cases against the provided code
-func (d *DockerClient) ExecuteTests(ctx context.Context, containerID, language string, testCases []TestCase) (bool, []TestCaseResult, SolutionResultDetails, string, error) {
+// ExecuteTests runs every test case against the compiled code and returns a
+// result per test. Runtime errors and timeouts fail only the affected test:
+// the remaining tests are still executed so that partial scores can be
+// computed, and ErrExecutionFailed is returned with the first error details.
+func (d *DockerClient) ExecuteTests(ctx context.Context, containerID, language string, ioSettings IOSettings, testCases []TestCase) ([]TestCaseResult, SolutionResultDetails, string, error) {
 	var (
-		failedTests []TestCaseResult
-		avgMemoryKB float64
-		avgTimeMS   float64
+		results      []TestCaseResult
+		avgMemoryKB  float64
+		avgTimeMS    float64
+		errorDetails string
+		execFailed   bool
 	)
-	isAllPassed := true
 
 	handler, err := GetLanguageHandler(language)
 	if err != nil {
-		return false, nil, SolutionResultDetails{}, "", err
+		return nil, SolutionResultDetails{}, "", err
 	}
 
-	runCmd := handler.GetRunCommand("/workspace")
+	// Limit the size of files the solution may write, dash counts ulimit -f in
+	// 512-byte blocks; one extra block lets us detect that the limit was hit
+	outputLimit := d.outputLimit()
+	runCmd := append([]string{"sh", "-c", fmt.Sprintf(`ulimit -f %d; exec "$@"`, outputLimit/512+1), "sh"},
+		handler.GetRunCommand(workspaceDir)...)
 
-	var cancel context.CancelFunc
-	ctx, cancel = context.WithTimeout(ctx, time.Duration(d.config.ExecutionTimeMS)*time.Millisecond)
-	defer cancel()
 	for _, tc := range testCases {
+		// Every test starts from a clean copy of the artifacts with no leftover processes
+		if err := d.resetWorkspace(ctx, containerID); err != nil {
+			return nil, SolutionResultDetails{}, "", err
+		}
+
 		// Get initial memory stats using Docker stats API instead of cgroup files
 		initialMemOut, _, _ := d.execCommand(ctx, containerID, "cat /sys/fs/cgroup/memory/memory.usage_in_bytes 2>/dev/null || cat /sys/fs/cgroup/memory.current 2>/dev/null || echo 0")
 
@@ -248,15 +411,49 @@ func (d *DockerClient) ExecuteTests(ctx context.Context, containerID, language s
 		resetCmd := "echo 0 > /sys/fs/cgroup/memory/memory.max_usage_in_bytes 2>/dev/null || true"
 		d.execCommand(ctx, containerID, resetCmd)
 
+		input, err := d.testData.OpenInput(ctx, tc)
+		if err != nil {
+			return nil, SolutionResultDetails{}, "", fmt.Errorf("failed to open test input: %w", err)
+		}
+
+		// In file mode the input is placed into the working directory instead of stdin
+		stdin := input
+		if ioSettings.Mode == IOModeFile {
+			err := d.writeInputFile(ctx, containerID, ioSettings.InputFile, input)
+			input.Close()
+			if err != nil {
+				return nil, SolutionResultDetails{}, "", err
+			}
+			stdin = io.NopCloser(strings.NewReader(""))
+		}
+
 		startTime := time.Now()
 
-		// Execute code
-		execResult, errDetails, err := d.runTestCase(ctx, containerID, runCmd, tc.Input)
-		if err != nil {
+		// Execute code, the time limit applies to each test separately
+		testCtx, cancel := context.WithTimeout(ctx, time.Duration(d.config.ExecutionTimeMS)*time.Millisecond)
+		execResult, errDetails, err := d.runTestCase(testCtx, containerID, runCmd, stdin)
+		cancel()
+		stdin.Close()
+		if err == nil && ioSettings.Mode == IOModeFile {
+			execResult, errDetails, err = d.readOutputFile(ctx, containerID, ioSettings.OutputFile, outputLimit)
+		}
+		if isTestVerdict(err) {
 			d.logger.Debug("execution error",
+				zap.Int("testcase_id", tc.ID),
 				zap.Error(err),
 				zap.String("error_details", errDetails))
-			return false, nil, SolutionResultDetails{}, errDetails, ErrExecutionFailed
+			if !execFailed {
+				execFailed = true
+				errorDetails = errDetails
+				if errDetails == "" {
+					errorDetails = err.Error()
+				}
+			}
+			results = append(results, TestCaseResult{TestCase: tc})
+			continue
+		}
+		if err != nil {
+			return nil, SolutionResultDetails{}, "", err
 		}
 
 		// End timing
@@ -270,7 +467,7 @@ func (d *DockerClient) ExecuteTests(ctx context.Context, containerID, language s
 
 		if errtestbuf.Len() > 0 || err != nil {
 			d.logger.Debug("failed to get peak memory", zap.String("stderr", errtestbuf.String()), zap.Error(err))
-			return false, nil, SolutionResultDetails{}, "", fmt.Errorf("failed to get peak memory")
+			return nil, SolutionResultDetails{}, "", fmt.Errorf("failed to get peak memory")
 		}
 
 		memStr := strings.TrimSpace(memOut.String())
@@ -289,16 +486,24 @@ func (d *DockerClient) ExecuteTests(ctx context.Context, containerID, language s
 
 		peakMemoryKB = peakMemoryKB - baseMemoryKB
 
+		expected, err := d.testData.ExpectedOutput(ctx, tc)
+		if err != nil {
+			return nil, SolutionResultDetails{}, "", fmt.Errorf("failed to read expected output: %w", err)
+		}
+
 		// Compare output
 		actual := strings.TrimSpace(execResult)
-		if actual != tc.Output {
-			isAllPassed = false
-			result := TestCaseResult{
-				TestCase:     tc,
-				ActualOutput: actual,
+		result := TestCaseResult{TestCase: tc}
+		if actual == strings.TrimSpace(expected) {
+			result.Passed = true
+			result.Score = 1
+		} else {
+			if len(actual) > maxReportedOutput {
+				actual = actual[:maxReportedOutput] + "..."
 			}
-			failedTests = append(failedTests, result)
+			result.ActualOutput = actual
 		}
+		results = append(results, result)
 
 		avgMemoryKB += peakMemoryKB
 		avgTimeMS += executionTime
@@ -313,13 +518,105 @@ func (d *DockerClient) ExecuteTests(ctx context.Context, containerID, language s
 	avgTimeMS = math.Round(avgTimeMS*100) / 100
 	avgMemoryKB = math.Round(avgMemoryKB*100) / 100
 
-	return isAllPassed, failedTests, SolutionResultDetails{AverageTime: avgTimeMS, AverageMemory: avgMemoryKB}, "", nil
+	details := SolutionResultDetails{AverageTime: avgTimeMS, AverageMemory: avgMemoryKB}
+	if execFailed {
+		return results, details, errorDetails, ErrExecutionFailed
+	}
+	return results, details, "", nil
+}
+
+// isTestVerdict reports whether the error fails only the current test rather than the whole run
+func isTestVerdict(err error) bool {
+	return errors.Is(err, ErrExecutionTimeout) ||
+		errors.Is(err, ErrExecutionFailed) ||
+		errors.Is(err, ErrOutputLimitExceeded)
+}
+
+// outputLimit returns the maximum size of the solution output in bytes
+func (d *DockerClient) outputLimit() int64 {
+	if d.config.OutputLimitKB <= 0 {
+		return defaultOutputLimitKB * 1024
+	}
+	return int64(d.config.OutputLimitKB) * 1024
+}
+
+// writeInputFile streams the test input into a root-owned, read-only file in the workspace
+func (d *DockerClient) writeInputFile(ctx context.Context, containerID, fileName string, input io.Reader) error {
+	path := workspaceDir + "/" + fileName
+	cmd := fmt.Sprintf("cat > %s && chmod 0444 %s", path, path)
+	_, stderr, err := d.execCommandWithInput(ctx, containerID, cmd, input)
+	if err != nil {
+		return fmt.Errorf("failed to write input file: %w", err)
+	}
+	if stderr.Len() > 0 {
+		return fmt.Errorf("failed to write input file: %s", stderr.String())
+	}
+	return nil
+}
+
+// readOutputFile returns the content of the output file written by the solution
+func (d *DockerClient) readOutputFile(ctx context.Context, containerID, fileName string, limit int64) (string, string, error) {
+	path := workspaceDir + "/" + fileName
+	cmd := fmt.Sprintf("if [ -f %s ]; then head -c %d %s; else echo 'output file %s not found' >&2; fi", path, limit+1, path, fileName)
+	stdout, stderr, err := d.execCommand(ctx, containerID, cmd)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read output file: %w", err)
+	}
+	if stderr.Len() > 0 {
+		return "", stderr.String(), ErrExecutionFailed
+	}
+	if int64(stdout.Len()) > limit {
+		return "", "", ErrOutputLimitExceeded
+	}
+	return stdout.String(), "", nil
+}
+
+// execCommandWithInput runs a command as root with the given stdin
+func (d *DockerClient) execCommandWithInput(ctx context.Context, containerID, cmd string, input io.Reader) (bytes.Buffer, bytes.Buffer, error) {
+	var outBuf, errBuf bytes.Buffer
+	execResp, err := d.client.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          []string{"sh", "-c", cmd},
+	})
+	if err != nil {
+		return outBuf, errBuf, err
+	}
+	attachResp, err := d.client.ContainerExecAttach(ctx, execResp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return outBuf, errBuf, err
+	}
+	defer attachResp.Close()
+
+	go func() {
+		io.Copy(attachResp.Conn, input)
+		attachResp.CloseWrite()
+	}()
+
+	_, err = stdcopy.StdCopy(&outBuf, &errBuf, attachResp.Reader)
+	return outBuf, errBuf, err
+}
+
+// limitedBuffer fails writes once more than limit bytes were written
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if int64(b.Len()+len(p)) > b.limit {
+		return 0, ErrOutputLimitExceeded
+	}
+	return b.Buffer.Write(p)
 }
 
 // runTestCase executes a single test case and returns its output
-func (d *DockerClient) runTestCase(ctx context.Context, containerID string, cmd []string, input string) (string, string, error) {
+func (d *DockerClient) runTestCase(ctx context.Context, containerID string, cmd []string, input io.Reader) (string, string, error) {
 	execConfig := container.ExecOptions{
 		Cmd:          cmd,
+		User:         sandboxUser,
+		WorkingDir:   workspaceDir,
 		AttachStdin:  true,
 		AttachStdout: true,
 		AttachStderr: true,
@@ -339,12 +636,12 @@ func (d *DockerClient) runTestCase(ctx context.Context, containerID string, cmd
 
 	// Send input in a goroutine
 	go func() {
-		io.Copy(resp.Conn, strings.NewReader(input))
+		io.Copy(resp.Conn, input)
 		resp.CloseWrite()
 	}()
 
 	// Collect output with context handling
-	outBuf := new(bytes.Buffer)
+	outBuf := &limitedBuffer{limit: d.outputLimit()}
 	errBuf := new(bytes.Buffer)
 
 	// Create a channel to signal when copying is done
@@ -378,12 +675,15 @@ func (d *DockerClient) runTestCase(ctx context.Context, containerID string, cmd
 		}
 
 		// Kill any runaway child processes too
-		d.execCommand(killCtx, containerID, "pkill -9 -f solution || true")
+		d.execCommand(killCtx, containerID, killSandboxScript+"kill_sandbox; true")
 
 		return "", "", ErrExecutionTimeout
 
 	case <-done:
 		// Normal completion
+		if errors.Is(copyErr, ErrOutputLimitExceeded) {
+			return "", "", ErrOutputLimitExceeded
+		}
 		if copyErr != nil {
 			return "", "", fmt.Errorf("failed to read output: %w", copyErr)
 		}
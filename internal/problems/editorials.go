@@ -0,0 +1,88 @@
+package problems
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrEditorialNotFound = errors.New("editorial not found")
+	ErrEditorialLocked   = errors.New("editorial is available after an accepted solution or giving up")
+	ErrHintNotFound      = errors.New("hint not found")
+	ErrNoMoreHints       = errors.New("all hints are already revealed")
+)
+
+// Editorial describes the intended solution of a problem
+type Editorial struct {
+	ProblemUUID string    `json:"problem_uuid"`
+	Content     string    `json:"content"` // sanitized HTML
+	Source      string    `json:"source,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Hint is one step of the progressive hints of a problem
+type Hint struct {
+	ID       int    `json:"id"`
+	Position int    `json:"position"`
+	Content  string `json:"content"` // sanitized HTML
+	Source   string `json:"source,omitempty"`
+}
+
+// HintsResponse lists the hints revealed to the user
+type HintsResponse struct {
+	Hints     []Hint `json:"hints"`
+	Total     int    `json:"total"`
+	Remaining int    `json:"remaining"`
+}
+
+// SetEditorialRequest replaces the editorial of a problem, the content is Markdown
+type SetEditorialRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// CreateHintRequest adds a hint; hints are revealed in position order
+type CreateHintRequest struct {
+	Position int    `json:"position"`
+	Content  string `json:"content" binding:"required"`
+}
+
+// GetHints returns the hints the user has revealed so far
+func (s *ProblemService) GetHints(userID, problemUUID string) (HintsResponse, error) {
+	all, err := s.ProblemRepo.GetHints(problemUUID)
+	if err != nil {
+		return HintsResponse{}, err
+	}
+	revealed, err := s.ProblemRepo.GetRevealedHints(userID, problemUUID)
+	if err != nil {
+		return HintsResponse{}, err
+	}
+	return HintsResponse{Hints: revealed, Total: len(all), Remaining: len(all) - len(revealed)}, nil
+}
+
+// RevealNextHint records that the user opened the next hint and returns all revealed hints
+func (s *ProblemService) RevealNextHint(userID, problemUUID string) (HintsResponse, error) {
+	if _, err := s.ProblemRepo.RevealNextHint(userID, problemUUID); err != nil {
+		return HintsResponse{}, err
+	}
+	return s.GetHints(userID, problemUUID)
+}
+
+// GetEditorial returns the editorial if the user solved the problem or gave up on it
+func (s *ProblemService) GetEditorial(userID string, problem *Problem) (Editorial, error) {
+	if !problem.Solved {
+		gaveUp, err := s.ProblemRepo.HasGivenUp(userID, problem.UUID)
+		if err != nil {
+			return Editorial{}, err
+		}
+		if !gaveUp {
+			return Editorial{}, ErrEditorialLocked
+		}
+	}
+
+	editorial, err := s.ProblemRepo.GetEditorial(problem.UUID)
+	if err != nil {
+		return Editorial{}, err
+	}
+	editorial.Source = ""
+	return editorial, nil
+}
@@ -0,0 +1,204 @@
+package problems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Lifecycle statuses of a problem. New problems start as drafts and only
+// published problems are visible to non-admin users.
+const (
+	ProblemStatusDraft     = "draft"
+	ProblemStatusPublished = "published"
+	ProblemStatusArchived  = "archived"
+)
+
+// Verdicts of author solutions, updated on every publishing check
+const (
+	AuthorSolutionPending  = "pending"
+	AuthorSolutionAccepted = "accepted"
+	AuthorSolutionRejected = "rejected"
+)
+
+// publishCheckInterval is how often scheduled publications are processed
+const publishCheckInterval = time.Minute
+
+var (
+	ErrInvalidProblemStatus   = errors.New("status must be draft, published or archived")
+	ErrInvalidPublishTime     = errors.New("publish_at is only allowed when publishing")
+	ErrNoAuthorSolution       = errors.New("problem has no author solution")
+	ErrAuthorSolutionFailed   = errors.New("no author solution passes all tests")
+	ErrAuthorSolutionNotFound = errors.New("author solution not found")
+)
+
+// SetStatusRequest changes the lifecycle status of a problem.
+// A future PublishAt schedules the publication instead of publishing right away.
+type SetStatusRequest struct {
+	Status    string     `json:"status" binding:"required"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// CreateAuthorSolutionRequest adds a reference solution to a problem
+type CreateAuthorSolutionRequest struct {
+	Language string `json:"language" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// SetStatus moves the problem to another lifecycle status
+func (s *ProblemService) SetStatus(ctx context.Context, problemUUID string, req SetStatusRequest) error {
+	switch req.Status {
+	case ProblemStatusPublished:
+		if req.PublishAt != nil && req.PublishAt.After(time.Now()) {
+			// Прогон авторских решений откладывается до момента публикации,
+			// сейчас проверяем только наличие тестов и решений
+			if err := s.checkPublishPrerequisites(problemUUID); err != nil {
+				return err
+			}
+			return s.ProblemRepo.SetProblemStatus(problemUUID, ProblemStatusDraft, req.PublishAt, "")
+		}
+		if err := s.CheckPublishable(ctx, problemUUID); err != nil {
+			return err
+		}
+		return s.ProblemRepo.SetProblemStatus(problemUUID, ProblemStatusPublished, nil, "")
+	case ProblemStatusDraft, ProblemStatusArchived:
+		if req.PublishAt != nil {
+			return ErrInvalidPublishTime
+		}
+		return s.ProblemRepo.SetProblemStatus(problemUUID, req.Status, nil, "")
+	default:
+		return ErrInvalidProblemStatus
+	}
+}
+
+func (s *ProblemService) checkPublishPrerequisites(problemUUID string) error {
+	if _, err := s.ProblemRepo.GetProblemByUUID(problemUUID, ""); err != nil {
+		return err
+	}
+	if _, err := s.ProblemRepo.GetTestCasesByProblemUUID(problemUUID); err != nil {
+		return err
+	}
+	solutions, err := s.ProblemRepo.GetAuthorSolutions(problemUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get author solutions: %w", err)
+	}
+	if len(solutions) == 0 {
+		return ErrNoAuthorSolution
+	}
+	return nil
+}
+
+// CheckPublishable runs every author solution against the current tests and
+// records the verdicts. The problem can be published if at least one passes.
+func (s *ProblemService) CheckPublishable(ctx context.Context, problemUUID string) error {
+	problem, err := s.ProblemRepo.GetProblemByUUID(problemUUID, "")
+	if err != nil {
+		return err
+	}
+	testCases, err := s.ProblemRepo.GetTestCasesByProblemUUID(problemUUID)
+	if err != nil {
+		return err
+	}
+	solutions, err := s.ProblemRepo.GetAuthorSolutions(problemUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get author solutions: %w", err)
+	}
+	if len(solutions) == 0 {
+		return ErrNoAuthorSolution
+	}
+	if err := s.TestData.Prefetch(ctx, testCases); err != nil {
+		return err
+	}
+
+	accepted := false
+	for _, sol := range solutions {
+		passed, err := s.runAuthorSolution(ctx, problem, testCases, sol)
+		if err != nil {
+			return fmt.Errorf("failed to run author solution %d: %w", sol.ID, err)
+		}
+
+		verdict := AuthorSolutionRejected
+		if passed {
+			verdict = AuthorSolutionAccepted
+			accepted = true
+		}
+		if err := s.ProblemRepo.SetAuthorSolutionStatus(sol.ID, verdict); err != nil {
+			return fmt.Errorf("failed to save author solution verdict: %w", err)
+		}
+	}
+
+	if !accepted {
+		return ErrAuthorSolutionFailed
+	}
+	return nil
+}
+
+// runAuthorSolution judges a reference solution without saving it as a submission
+func (s *ProblemService) runAuthorSolution(ctx context.Context, problem *Problem, testCases []TestCase, sol AuthorSolution) (bool, error) {
+	containerID, _, err := s.DockerClient.CreateContainer(ctx, sol.Code, sol.Language)
+	defer func() {
+		<-s.DockerClient.semaphore
+		if containerID != "" {
+			if err := s.DockerClient.RemoveContainer(ctx, containerID); err != nil {
+				s.Logger.Error("failed to remove container", zap.String("container_id", containerID), zap.Error(err))
+			}
+		}
+	}()
+
+	if errors.Is(err, ErrCompilationFailed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create Docker container: %w", err)
+	}
+
+	results, _, _, err := s.DockerClient.ExecuteTests(ctx, containerID, sol.Language, problem.IOSettings, testCases)
+	if err != nil && !errors.Is(err, ErrExecutionFailed) {
+		return false, fmt.Errorf("failed to execute code: %w", err)
+	}
+
+	passed := len(results) == len(testCases)
+	for _, r := range results {
+		passed = passed && r.Passed
+	}
+	return passed, nil
+}
+
+// RunPublishScheduler publishes problems whose scheduled time has come until ctx is cancelled
+func (s *ProblemService) RunPublishScheduler(ctx context.Context) {
+	ticker := time.NewTicker(publishCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.publishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ProblemService) publishDue(ctx context.Context) {
+	due, err := s.ProblemRepo.GetDueScheduledProblems(time.Now())
+	if err != nil {
+		s.Logger.Error("failed to get scheduled problems", zap.Error(err))
+		return
+	}
+
+	for _, problemUUID := range due {
+		status, publishError := ProblemStatusPublished, ""
+		if err := s.CheckPublishable(ctx, problemUUID); err != nil {
+			// Задача остаётся черновиком, причина видна администратору
+			s.Logger.Warn("scheduled publication failed", zap.String("uuid", problemUUID), zap.Error(err))
+			status, publishError = ProblemStatusDraft, err.Error()
+		}
+		if err := s.ProblemRepo.SetProblemStatus(problemUUID, status, nil, publishError); err != nil {
+			s.Logger.Error("failed to update problem status", zap.String("uuid", problemUUID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package problems
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Sort orders of the problem list
+const (
+	SortByID         = "id"
+	SortByNewest     = "newest"
+	SortByAcceptance = "acceptance"
+)
+
+// Solved status filters of the problem list
+const (
+	FilterSolved   = "solved"
+	FilterUnsolved = "unsolved"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidQuery  = errors.New("invalid problem list query")
+	ErrInvalidTag    = errors.New("invalid tag name")
+)
+
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} _+#.-]{0,49}$`)
+
+// ProblemListQuery contains filters, sort order and pagination of the problem list
+type ProblemListQuery struct {
+	Difficulties []string
+	Tags         []string
+	Status       string
+	// ProblemStatuses limits lifecycle statuses, empty means any (admins and authors only)
+	ProblemStatuses []string
+	// EditorUUID hides unpublished problems the user neither owns nor co-authors
+	EditorUUID string
+	Search     string
+	Sort       string
+	Limit      int
+	Cursor     *ListCursor
+	// Locales is the preference list for problem names
+	Locales []string
+}
+
+// ListCursor points right after the last problem of the previous page
+type ListCursor struct {
+	ID         int       `json:"id"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+	Acceptance float64   `json:"acceptance,omitempty"`
+}
+
+// ProblemSummary is a problem list entry without the statement
+type ProblemSummary struct {
+	ID             int       `json:"id"`
+	UUID           string    `json:"uuid"`
+	Name           string    `json:"name"`
+	Locale         string    `json:"locale"`
+	Difficulty     string    `json:"difficulty"`
+	Rating         *float64  `json:"rating,omitempty"`
+	Tags           []string  `json:"tags"`
+	Solved         bool      `json:"solved"`
+	MaxScore       float64   `json:"max_score"`
+	BestScore      float64   `json:"best_score"`
+	AcceptanceRate float64   `json:"acceptance_rate"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ProblemPage is a single page of the problem list
+type ProblemPage struct {
+	Problems   []ProblemSummary `json:"problems"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// EncodeCursor serializes the position after the summary into an opaque string
+func EncodeCursor(p ProblemSummary) string {
+	data, _ := json.Marshal(ListCursor{ID: p.ID, CreatedAt: p.CreatedAt, Acceptance: p.AcceptanceRate})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by EncodeCursor
+func DecodeCursor(raw string) (*ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor ListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// Validate checks the query values and applies defaults
+func (q *ProblemListQuery) Validate() error {
+	for _, d := range q.Difficulties {
+		if d != "easy" && d != "medium" && d != "hard" {
+			return ErrInvalidQuery
+		}
+	}
+	switch q.Status {
+	case "", FilterSolved, FilterUnsolved:
+	default:
+		return ErrInvalidQuery
+	}
+	for _, s := range q.ProblemStatuses {
+		if s != ProblemStatusDraft && s != ProblemStatusPublished && s != ProblemStatusArchived {
+			return ErrInvalidQuery
+		}
+	}
+	switch q.Sort {
+	case "":
+		q.Sort = SortByID
+	case SortByID, SortByNewest, SortByAcceptance:
+	default:
+		return ErrInvalidQuery
+	}
+	if q.Limit <= 0 {
+		q.Limit = DefaultPageSize
+	}
+	if q.Limit > MaxPageSize {
+		q.Limit = MaxPageSize
+	}
+	q.Search = strings.TrimSpace(q.Search)
+	return nil
+}
+
+// NormalizeTags trims, deduplicates and validates tag names
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, ErrInvalidTag
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// ListProblems returns a page of problem summaries matching the query
+func (s *ProblemService) ListProblems(userID string, q ProblemListQuery) (ProblemPage, error) {
+	if err := q.Validate(); err != nil {
+		return ProblemPage{}, err
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	summaries, err := s.ProblemRepo.ListProblems(userID, q, q.Limit+1)
+	if err != nil {
+		return ProblemPage{}, err
+	}
+
+	page := ProblemPage{Problems: summaries}
+	if len(summaries) > q.Limit {
+		page.Problems = summaries[:q.Limit]
+		page.NextCursor = EncodeCursor(page.Problems[q.Limit-1])
+	}
+	if err := s.localizeSummaries(page.Problems, q.Locales); err != nil {
+		return ProblemPage{}, err
+	}
+	return page, nil
+}
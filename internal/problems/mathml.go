@@ -0,0 +1,406 @@
+package problems
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// TeX → MathML для формул в условиях. Поддерживается подмножество KaTeX,
+// которого хватает для олимпиадных условий: индексы, дроби, корни, скобки
+// \left/\right, греческие буквы, основные отношения и операторы, \text.
+// Неизвестные команды выводятся как <merror>, чтобы автор увидел опечатку.
+
+const maxMathDepth = 64
+
+var mathIdentifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"sigma": "σ", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ", "chi": "χ",
+	"psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	"infty": "∞", "emptyset": "∅", "varnothing": "∅", "partial": "∂", "nabla": "∇",
+	"ell": "ℓ", "aleph": "ℵ",
+}
+
+var mathOperators = map[string]string{
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠",
+	"lt": "<", "gt": ">", "ll": "≪", "gg": "≫", "approx": "≈", "equiv": "≡", "sim": "∼",
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "circ": "∘",
+	"oplus": "⊕", "otimes": "⊗", "wedge": "∧", "land": "∧", "vee": "∨", "lor": "∨",
+	"neg": "¬", "lnot": "¬", "cup": "∪", "cap": "∩", "setminus": "∖",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃", "supseteq": "⊇",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺",
+	"mapsto": "↦", "forall": "∀", "exists": "∃", "mid": "∣", "parallel": "∥", "perp": "⊥",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉", "langle": "⟨", "rangle": "⟩",
+	"vert": "|", "Vert": "‖", "prime": "′",
+	"{": "{", "}": "}", "|": "‖", "%": "%", "$": "$", "&": "&", "#": "#", "_": "_",
+}
+
+// Операторы с пределами: в display-режиме индексы пишутся над и под знаком
+var mathLargeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "oint": "∮",
+	"bigcup": "⋃", "bigcap": "⋂", "bigoplus": "⨁", "bigwedge": "⋀", "bigvee": "⋁",
+}
+
+var mathFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "arcsin": true, "arccos": true, "arctan": true,
+	"log": true, "ln": true, "lg": true, "exp": true, "det": true, "deg": true, "dim": true,
+	"gcd": true, "max": true, "min": true, "sup": true, "inf": true, "lim": true,
+	"limsup": true, "liminf": true, "arg": true, "ker": true, "Pr": true,
+}
+
+// Функции с пределами, как у \max_{i}
+var mathLimitFunctions = map[string]bool{"max": true, "min": true, "sup": true, "inf": true, "lim": true, "limsup": true, "liminf": true, "gcd": true, "det": true, "Pr": true}
+
+var mathSpaces = map[string]string{
+	",": "0.1667em", ":": "0.2222em", ">": "0.2222em", ";": "0.2778em", " ": "0.25em",
+	"quad": "1em", "qquad": "2em", "!": "-0.1667em",
+}
+
+var mathAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "‾", "overline": "‾", "vec": "→", "overrightarrow": "→",
+	"tilde": "~", "widetilde": "~", "dot": "˙", "ddot": "¨",
+}
+
+var mathVariants = map[string]string{
+	"mathbb": "double-struck", "mathbf": "bold", "mathit": "italic", "mathrm": "normal",
+	"mathcal": "script", "mathsf": "sans-serif", "mathtt": "monospace", "boldsymbol": "bold",
+}
+
+// TeXToMathML converts a TeX formula to a MathML element. Display formulas are rendered as blocks.
+func TeXToMathML(tex string, display bool) string {
+	p := &texParser{src: []rune(tex), display: display}
+	body := p.parseSequence("")
+
+	var b strings.Builder
+	b.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML"`)
+	if display {
+		b.WriteString(` display="block"`)
+	}
+	b.WriteString(`><semantics><mrow>`)
+	b.WriteString(body)
+	b.WriteString(`</mrow><annotation encoding="application/x-tex">`)
+	b.WriteString(html.EscapeString(tex))
+	b.WriteString(`</annotation></semantics></math>`)
+	return b.String()
+}
+
+type texParser struct {
+	src     []rune
+	pos     int
+	depth   int
+	display bool
+}
+
+func (p *texParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *texParser) skipSpaces() {
+	for !p.eof() && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// parseSequence reads atoms until the closing token ("}" or "right") or the end of the formula
+func (p *texParser) parseSequence(closing string) string {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxMathDepth {
+		p.pos = len(p.src)
+		return mathError("formula is nested too deeply")
+	}
+
+	var b strings.Builder
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return b.String()
+		}
+		if closing == "}" && p.src[p.pos] == '}' {
+			p.pos++
+			return b.String()
+		}
+		if closing == "right" && p.peekCommand() == "right" {
+			return b.String()
+		}
+		b.WriteString(p.parseScripts(p.parseAtom()))
+	}
+}
+
+// parseScripts attaches ^ and _ following the base
+func (p *texParser) parseScripts(base texAtom) string {
+	var sub, sup string
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			break
+		}
+		switch c := p.src[p.pos]; {
+		case c == '_' && sub == "":
+			p.pos++
+			sub = p.parseArgument()
+		case c == '^' && sup == "":
+			p.pos++
+			sup = p.parseArgument()
+		case c == '\'' && sup == "":
+			p.pos++
+			sup = "<mo>′</mo>"
+		default:
+			goto done
+		}
+	}
+done:
+	if sub == "" && sup == "" {
+		return base.markup
+	}
+
+	under, over := "msub", "msup"
+	both := "msubsup"
+	if base.limits && p.display {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case sup == "":
+		return "<" + under + ">" + base.markup + sub + "</" + under + ">"
+	case sub == "":
+		return "<" + over + ">" + base.markup + sup + "</" + over + ">"
+	default:
+		return "<" + both + ">" + base.markup + sub + sup + "</" + both + ">"
+	}
+}
+
+type texAtom struct {
+	markup string
+	limits bool
+}
+
+// parseArgument reads a single-token or braced argument of a command or a script as one <mrow>
+func (p *texParser) parseArgument() string {
+	p.skipSpaces()
+	if p.eof() {
+		return mathError("missing argument")
+	}
+	if p.src[p.pos] == '{' {
+		p.pos++
+		return wrapRow(p.parseSequence("}"))
+	}
+	return wrapRow(p.parseAtom().markup)
+}
+
+// parseRawArgument returns the text of a braced argument without parsing it
+func (p *texParser) parseRawArgument() string {
+	p.skipSpaces()
+	if p.eof() || p.src[p.pos] != '{' {
+		if p.eof() {
+			return ""
+		}
+		p.pos++
+		return string(p.src[p.pos-1])
+	}
+	p.pos++
+	start, level := p.pos, 1
+	for ; !p.eof(); p.pos++ {
+		switch p.src[p.pos] {
+		case '{':
+			level++
+		case '}':
+			level--
+		case '\\':
+			p.pos++
+			continue
+		}
+		if level == 0 {
+			text := string(p.src[start:p.pos])
+			p.pos++
+			return text
+		}
+	}
+	return string(p.src[start:])
+}
+
+func (p *texParser) peekCommand() string {
+	if p.eof() || p.src[p.pos] != '\\' {
+		return ""
+	}
+	end := p.pos + 1
+	for end < len(p.src) && unicode.IsLetter(p.src[end]) {
+		end++
+	}
+	return string(p.src[p.pos+1 : end])
+}
+
+func (p *texParser) readCommand() string {
+	p.pos++ // backslash
+	if p.eof() {
+		return ""
+	}
+	if !unicode.IsLetter(p.src[p.pos]) {
+		p.pos++
+		return string(p.src[p.pos-1])
+	}
+	start := p.pos
+	for !p.eof() && unicode.IsLetter(p.src[p.pos]) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *texParser) parseAtom() texAtom {
+	c := p.src[p.pos]
+	switch {
+	case c == '{':
+		p.pos++
+		return texAtom{markup: wrapRow(p.parseSequence("}"))}
+	case c == '}':
+		p.pos++
+		return texAtom{markup: mathError("unexpected }")}
+	case c == '\\':
+		return p.parseCommand()
+	case unicode.IsDigit(c):
+		start := p.pos
+		for !p.eof() && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(p.src[p.pos+1])) {
+			p.pos++
+		}
+		return texAtom{markup: "<mn>" + string(p.src[start:p.pos]) + "</mn>"}
+	case unicode.IsLetter(c):
+		p.pos++
+		return texAtom{markup: "<mi>" + html.EscapeString(string(c)) + "</mi>"}
+	case c == '^' || c == '_':
+		// Индекс без основания, как в {}^{a}
+		return texAtom{markup: "<mrow></mrow>"}
+	case c == '~':
+		p.pos++
+		return texAtom{markup: `<mspace width="0.25em"></mspace>`}
+	}
+
+	p.pos++
+	switch c {
+	case '-':
+		return texAtom{markup: "<mo>−</mo>"}
+	case '*':
+		return texAtom{markup: "<mo>∗</mo>"}
+	case '(', ')', '[', ']', '|':
+		return texAtom{markup: `<mo stretchy="false">` + string(c) + "</mo>"}
+	}
+	return texAtom{markup: "<mo>" + html.EscapeString(string(c)) + "</mo>"}
+}
+
+func (p *texParser) parseCommand() texAtom {
+	name := p.readCommand()
+	if v, ok := mathIdentifiers[name]; ok {
+		variant := ""
+		if unicode.IsUpper([]rune(v)[0]) || len([]rune(v)) == 1 && !unicode.IsLetter([]rune(v)[0]) {
+			variant = ` mathvariant="normal"`
+		}
+		return texAtom{markup: "<mi" + variant + ">" + v + "</mi>"}
+	}
+	if v, ok := mathOperators[name]; ok {
+		return texAtom{markup: "<mo>" + html.EscapeString(v) + "</mo>"}
+	}
+	if v, ok := mathLargeOperators[name]; ok {
+		return texAtom{markup: `<mo largeop="true" movablelimits="true">` + v + "</mo>", limits: !strings.HasPrefix(name, "i") && name != "oint"}
+	}
+	if mathFunctions[name] {
+		return texAtom{markup: "<mi>" + name + "</mi><mo>⁡</mo>", limits: mathLimitFunctions[name]}
+	}
+	if width, ok := mathSpaces[name]; ok {
+		return texAtom{markup: `<mspace width="` + width + `"></mspace>`}
+	}
+	if accent, ok := mathAccents[name]; ok {
+		return texAtom{markup: `<mover accent="true">` + p.parseArgument() + "<mo>" + html.EscapeString(accent) + "</mo></mover>"}
+	}
+	if variant, ok := mathVariants[name]; ok {
+		text := p.parseRawArgument()
+		return texAtom{markup: `<mi mathvariant="` + variant + `">` + html.EscapeString(text) + "</mi>"}
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		num := p.parseArgument()
+		den := p.parseArgument()
+		return texAtom{markup: "<mfrac>" + num + den + "</mfrac>"}
+	case "binom":
+		top := p.parseArgument()
+		bottom := p.parseArgument()
+		return texAtom{markup: `<mrow><mo>(</mo><mfrac linethickness="0">` + top + bottom + `</mfrac><mo>)</mo></mrow>`}
+	case "sqrt":
+		p.skipSpaces()
+		if !p.eof() && p.src[p.pos] == '[' {
+			p.pos++
+			start := p.pos
+			for !p.eof() && p.src[p.pos] != ']' {
+				p.pos++
+			}
+			index := (&texParser{src: p.src[start:p.pos], depth: p.depth, display: p.display}).parseSequence("")
+			if !p.eof() {
+				p.pos++
+			}
+			return texAtom{markup: "<mroot>" + p.parseArgument() + wrapRow(index) + "</mroot>"}
+		}
+		return texAtom{markup: "<msqrt>" + p.parseArgument() + "</msqrt>"}
+	case "text", "textrm", "textit", "textbf", "mbox":
+		return texAtom{markup: "<mtext>" + html.EscapeString(p.parseRawArgument()) + "</mtext>"}
+	case "operatorname":
+		return texAtom{markup: "<mi>" + html.EscapeString(p.parseRawArgument()) + "</mi><mo>⁡</mo>"}
+	case "bmod":
+		return texAtom{markup: `<mo lspace="0.2222em" rspace="0.2222em">mod</mo>`}
+	case "pmod":
+		return texAtom{markup: `<mrow><mspace width="1em"></mspace><mo>(</mo><mi>mod</mi><mspace width="0.3333em"></mspace>` + p.parseArgument() + "<mo>)</mo></mrow>"}
+	case "left":
+		open := p.parseDelimiter()
+		body := p.parseSequence("right")
+		closeDelim := ""
+		if p.peekCommand() == "right" {
+			p.readCommand()
+			closeDelim = p.parseDelimiter()
+		}
+		return texAtom{markup: "<mrow>" + fence(open) + body + fence(closeDelim) + "</mrow>"}
+	case "right":
+		return texAtom{markup: mathError(`\right without \left`)}
+	case "displaystyle":
+		return texAtom{markup: ""}
+	case "limits":
+		return texAtom{markup: ""}
+	}
+	return texAtom{markup: mathError(`\` + name)}
+}
+
+// parseDelimiter reads the delimiter after \left or \right
+func (p *texParser) parseDelimiter() string {
+	p.skipSpaces()
+	if p.eof() {
+		return ""
+	}
+	if p.src[p.pos] == '\\' {
+		name := p.readCommand()
+		if v, ok := mathOperators[name]; ok {
+			return v
+		}
+		return ""
+	}
+	c := p.src[p.pos]
+	p.pos++
+	if c == '.' {
+		return ""
+	}
+	return string(c)
+}
+
+func fence(delim string) string {
+	if delim == "" {
+		return ""
+	}
+	return `<mo fence="true" stretchy="true">` + html.EscapeString(delim) + "</mo>"
+}
+
+func wrapRow(markup string) string {
+	return "<mrow>" + markup + "</mrow>"
+}
+
+func mathError(message string) string {
+	return "<merror><mtext>" + html.EscapeString(message) + "</mtext></merror>"
+}
@@ -0,0 +1,466 @@
+package problems
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem packages follow the layout of the ICPC problem package format
+// (https://icpc.io/problem-package-format/) with a few extensions:
+//
+//	problem.yaml                     name, difficulty, keywords (tags), limits, io settings
+//	problem_statement/problem.md     statement in Markdown (problem.html is accepted on import)
+//	data/sample/NNN.in, NNN.ans      tests without a subtask (data/secret works too)
+//	data/secret/NNN.in, NNN.ans      tests without a subtask
+//	data/secret/<group>/testdata.yaml  subtask settings: score, grading (min/all), description
+//	data/secret/<group>/NNN.in, .ans   tests of the subtask, groups are ordered by directory name
+//	submissions/accepted/*.{py,cpp,java}  author solutions
+//
+// Limits are informational: the judge applies its runtime configuration.
+// Output validators (custom checkers) are not supported; they are skipped on
+// import with a warning and the default token comparison is used.
+
+const (
+	packageManifest      = "problem.yaml"
+	packageStatement     = "problem_statement/problem.md"
+	packageStatementHTML = "problem_statement/problem.html"
+	packageTestGroup     = "testdata.yaml"
+
+	// MaxPackageSize limits the size of an imported archive
+	MaxPackageSize = 256 << 20
+)
+
+var ErrInvalidPackage = errors.New("invalid problem package")
+
+// packageManifestFile is the content of problem.yaml
+type packageManifestFile struct {
+	Name       string        `yaml:"name"`
+	Difficulty string        `yaml:"difficulty"`
+	Keywords   []string      `yaml:"keywords,omitempty"`
+	Limits     packageLimits `yaml:"limits,omitempty"`
+	IO         *packageIO    `yaml:"io,omitempty"`
+}
+
+type packageLimits struct {
+	TimeLimit float64 `yaml:"time_limit,omitempty"` // seconds
+	Memory    int     `yaml:"memory,omitempty"`     // MiB
+	Output    int     `yaml:"output,omitempty"`     // MiB
+}
+
+type packageIO struct {
+	Mode       string `yaml:"mode"`
+	InputFile  string `yaml:"input_file,omitempty"`
+	OutputFile string `yaml:"output_file,omitempty"`
+}
+
+// packageTestGroupFile is the content of data/secret/<group>/testdata.yaml
+type packageTestGroupFile struct {
+	Score       float64 `yaml:"score"`
+	Grading     string  `yaml:"grading,omitempty"`
+	Description string  `yaml:"description,omitempty"`
+}
+
+// AuthorSolution is a reference solution of a problem
+type AuthorSolution struct {
+	ID          int        `json:"id"`
+	ProblemUUID string     `json:"problem_uuid"`
+	Language    string     `json:"language"`
+	Code        string     `json:"code"`
+	Status      string     `json:"status"` // verdict of the last publishing check
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+}
+
+// ImportResult describes an imported package
+type ImportResult struct {
+	UUID      string   `json:"uuid"`
+	Tests     int      `json:"tests"`
+	Subtasks  int      `json:"subtasks"`
+	Solutions int      `json:"solutions"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+type packageTest struct {
+	name           string
+	input, output  *zip.File
+	subtaskOrdinal int // index in the sorted group list, -1 without a subtask
+}
+
+// ImportPackage creates a new problem from a zip archive, see the package layout above
+func (s *ProblemService) ImportPackage(ctx context.Context, r io.ReaderAt, size int64, authorUUID string, problemUUID string) (*ImportResult, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[strings.TrimPrefix(path.Clean(f.Name), "/")] = f
+	}
+	files = stripCommonRoot(files)
+
+	result := &ImportResult{UUID: problemUUID}
+
+	var manifest packageManifestFile
+	if err := readYAML(files[packageManifest], &manifest); err != nil {
+		return nil, fmt.Errorf("%w: problem.yaml: %v", ErrInvalidPackage, err)
+	}
+	if manifest.Name == "" {
+		return nil, fmt.Errorf("%w: problem.yaml: name is required", ErrInvalidPackage)
+	}
+	if manifest.Difficulty == "" {
+		manifest.Difficulty = "medium"
+		result.Warnings = append(result.Warnings, "difficulty is not set, using medium")
+	}
+
+	req := CreateProblemRequest{Name: manifest.Name, Difficulty: manifest.Difficulty}
+	if manifest.IO != nil {
+		req.IOSettings = IOSettings{Mode: manifest.IO.Mode, InputFile: manifest.IO.InputFile, OutputFile: manifest.IO.OutputFile}
+	}
+	if req.IOSettings, err = req.IOSettings.Normalize(); err != nil {
+		return nil, fmt.Errorf("%w: problem.yaml: %v", ErrInvalidPackage, err)
+	}
+	if req.Tags, err = NormalizeTags(manifest.Keywords); err != nil {
+		return nil, fmt.Errorf("%w: problem.yaml: %v", ErrInvalidPackage, err)
+	}
+
+	// HTML-условие подходит как исходник: Markdown пропускает разметку, санитайзер её чистит
+	statement, ok := files[packageStatement]
+	if !ok {
+		statement, ok = files[packageStatementHTML]
+	}
+	if ok {
+		data, err := readZipFile(statement)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+		}
+		req.Description = string(data)
+	} else {
+		result.Warnings = append(result.Warnings, "problem_statement/problem.md is missing")
+	}
+	if err := req.RenderDescription(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+
+	// Collect tests and subtask groups
+	groupNames := []string{}
+	groups := map[string]packageTestGroupFile{}
+	tests := map[string]*packageTest{}
+	var solutions []AuthorSolution
+	for name, f := range files {
+		dir, base := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case strings.HasPrefix(name, "output_validators/"):
+			if !strings.HasSuffix(name, "/") {
+				result.Warnings = append(result.Warnings, "output validators are not supported, skipped "+name)
+			}
+		case strings.HasPrefix(name, "submissions/accepted/") && dir == "submissions/accepted":
+			language, ok := LanguageByExtension(path.Ext(base))
+			if !ok {
+				result.Warnings = append(result.Warnings, "unsupported author solution language, skipped "+name)
+				continue
+			}
+			code, err := readZipFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+			}
+			solutions = append(solutions, AuthorSolution{Language: language, Code: string(code)})
+		case strings.HasPrefix(name, "data/") && base == packageTestGroup:
+			if dir == "data/secret" || dir == "data/sample" {
+				continue
+			}
+			var group packageTestGroupFile
+			if err := readYAML(f, &group); err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPackage, name, err)
+			}
+			groups[dir] = group
+		case strings.HasPrefix(name, "data/") && (strings.HasSuffix(base, ".in") || strings.HasSuffix(base, ".ans")):
+			key := strings.TrimSuffix(strings.TrimSuffix(name, ".in"), ".ans")
+			tc, ok := tests[key]
+			if !ok {
+				tc = &packageTest{name: key, subtaskOrdinal: -1}
+				tests[key] = tc
+			}
+			if strings.HasSuffix(base, ".in") {
+				tc.input = f
+			} else {
+				tc.output = f
+			}
+		}
+	}
+
+	for dir := range groups {
+		if !strings.HasPrefix(dir, "data/secret/") || strings.Count(dir, "/") != 2 {
+			return nil, fmt.Errorf("%w: subtask groups must be placed directly in data/secret, got %s", ErrInvalidPackage, dir)
+		}
+		groupNames = append(groupNames, dir)
+	}
+	sort.Strings(groupNames)
+
+	ordered := make([]*packageTest, 0, len(tests))
+	for _, tc := range tests {
+		if tc.input == nil || tc.output == nil {
+			return nil, fmt.Errorf("%w: test %s must have both .in and .ans files", ErrInvalidPackage, tc.name)
+		}
+		dir := path.Dir(tc.name)
+		switch {
+		case dir == "data/sample" || dir == "data/secret":
+		case slices.Index(groupNames, dir) >= 0:
+			tc.subtaskOrdinal = slices.Index(groupNames, dir)
+		default:
+			return nil, fmt.Errorf("%w: test %s is in a directory without testdata.yaml", ErrInvalidPackage, tc.name)
+		}
+		ordered = append(ordered, tc)
+	}
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("%w: package has no tests", ErrInvalidPackage)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].name < ordered[j].name })
+	sort.Strings(result.Warnings)
+
+	// Everything is validated, create the problem; on failure the partially
+	// imported problem is deleted together with its dependent rows
+	if err := s.ProblemRepo.AddProblem(problemUUID, authorUUID, req); err != nil {
+		return nil, fmt.Errorf("failed to add problem: %w", err)
+	}
+	if err := s.importContent(ctx, problemUUID, req.Tags, groupNames, groups, ordered, solutions); err != nil {
+		if delErr := s.ProblemRepo.DeleteProblem(problemUUID); delErr != nil {
+			s.Logger.Error("failed to clean up partially imported problem", zap.String("uuid", problemUUID), zap.Error(delErr))
+		}
+		return nil, err
+	}
+
+	result.Tests = len(ordered)
+	result.Subtasks = len(groupNames)
+	result.Solutions = len(solutions)
+	return result, nil
+}
+
+func (s *ProblemService) importContent(ctx context.Context, problemUUID string, tags []string, groupNames []string,
+	groups map[string]packageTestGroupFile, tests []*packageTest, solutions []AuthorSolution) error {
+	if len(tags) > 0 {
+		if err := s.ProblemRepo.SetProblemTags(problemUUID, tags); err != nil {
+			return fmt.Errorf("failed to set tags: %w", err)
+		}
+	}
+
+	subtaskIDs := make([]int, len(groupNames))
+	for i, name := range groupNames {
+		group := groups[name]
+		scoring, err := ValidateScoring(group.Grading)
+		if err != nil || group.Score <= 0 {
+			return fmt.Errorf("%w: %s/testdata.yaml: score must be positive and grading min or all", ErrInvalidPackage, name)
+		}
+		subtaskIDs[i], err = s.ProblemRepo.AddSubtask(problemUUID, CreateSubtaskRequest{
+			Position:    i + 1,
+			Points:      group.Score,
+			Scoring:     scoring,
+			Description: group.Description,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add subtask: %w", err)
+		}
+	}
+
+	for _, tc := range tests {
+		var subtaskID *int
+		if tc.subtaskOrdinal >= 0 {
+			subtaskID = &subtaskIDs[tc.subtaskOrdinal]
+		}
+		if err := s.addTestcaseFromZip(ctx, problemUUID, tc, subtaskID); err != nil {
+			return err
+		}
+	}
+
+	for _, sol := range solutions {
+		if _, err := s.ProblemRepo.AddAuthorSolution(problemUUID, sol.Language, sol.Code); err != nil {
+			return fmt.Errorf("failed to add author solution: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *ProblemService) addTestcaseFromZip(ctx context.Context, problemUUID string, tc *packageTest, subtaskID *int) error {
+	input, err := tc.input.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	defer input.Close()
+	output, err := tc.output.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPackage, err)
+	}
+	defer output.Close()
+
+	if err := s.AddTestcase(ctx, problemUUID, input, output, subtaskID); err != nil {
+		return fmt.Errorf("failed to add test %s: %w", tc.name, err)
+	}
+	return nil
+}
+
+// ExportPackage writes the problem as a zip archive in the package layout
+func (s *ProblemService) ExportPackage(ctx context.Context, problemUUID string, w io.Writer) error {
+	problem, err := s.ProblemRepo.GetProblemByUUID(problemUUID, "")
+	if err != nil {
+		return err
+	}
+	subtasks, err := s.ProblemRepo.GetSubtasksByProblemUUID(problemUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get subtasks: %w", err)
+	}
+	testCases, err := s.ProblemRepo.GetTestCasesByProblemUUID(problemUUID)
+	if err != nil && !errors.Is(err, ErrTestCasesNotFound) {
+		return fmt.Errorf("failed to get test cases: %w", err)
+	}
+	solutions, err := s.ProblemRepo.GetAuthorSolutions(problemUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get author solutions: %w", err)
+	}
+
+	archive := zip.NewWriter(w)
+
+	manifest := packageManifestFile{
+		Name:       problem.Name,
+		Difficulty: problem.Difficulty,
+		Keywords:   problem.Tags,
+		Limits: packageLimits{
+			TimeLimit: float64(s.DockerClient.config.ExecutionTimeMS) / 1000,
+			Memory:    s.DockerClient.config.MemoryLimitMB,
+			Output:    int(s.DockerClient.outputLimit() >> 20),
+		},
+	}
+	if problem.Mode == IOModeFile {
+		manifest.IO = &packageIO{Mode: problem.Mode, InputFile: problem.InputFile, OutputFile: problem.OutputFile}
+	}
+	if err := writeYAML(archive, packageManifest, manifest); err != nil {
+		return err
+	}
+	if err := writeZipFile(archive, packageStatement, strings.NewReader(problem.DescriptionSource)); err != nil {
+		return err
+	}
+
+	groupDirs := make(map[int]string, len(subtasks))
+	for i, st := range subtasks {
+		dir := fmt.Sprintf("data/secret/subtask%02d", i+1)
+		groupDirs[st.ID] = dir
+		err := writeYAML(archive, dir+"/"+packageTestGroup, packageTestGroupFile{
+			Score:       st.Points,
+			Grading:     st.Scoring,
+			Description: st.Description,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, tc := range testCases {
+		dir := "data/secret"
+		if tc.SubtaskID != nil && groupDirs[*tc.SubtaskID] != "" {
+			dir = groupDirs[*tc.SubtaskID]
+		}
+		base := fmt.Sprintf("%s/%03d", dir, i+1)
+
+		input, err := s.TestData.OpenInput(ctx, tc)
+		if err != nil {
+			return fmt.Errorf("failed to open input of test %d: %w", tc.ID, err)
+		}
+		err = writeZipFile(archive, base+".in", input)
+		input.Close()
+		if err != nil {
+			return err
+		}
+
+		expected, err := s.TestData.ExpectedOutput(ctx, tc)
+		if err != nil {
+			return fmt.Errorf("failed to open output of test %d: %w", tc.ID, err)
+		}
+		if err := writeZipFile(archive, base+".ans", strings.NewReader(expected)); err != nil {
+			return err
+		}
+	}
+
+	for i, sol := range solutions {
+		handler, err := GetLanguageHandler(sol.Language)
+		if err != nil {
+			continue
+		}
+		name := fmt.Sprintf("submissions/accepted/%d_%s", i+1, handler.GetSourceFilename())
+		if err := writeZipFile(archive, name, strings.NewReader(sol.Code)); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// stripCommonRoot removes a single top-level directory that wraps the whole package
+func stripCommonRoot(files map[string]*zip.File) map[string]*zip.File {
+	if _, ok := files[packageManifest]; ok {
+		return files
+	}
+	root := ""
+	for name := range files {
+		first, _, _ := strings.Cut(name, "/")
+		if root == "" {
+			root = first
+		} else if root != first {
+			return files
+		}
+	}
+	stripped := make(map[string]*zip.File, len(files))
+	for name, f := range files {
+		if rest := strings.TrimPrefix(name, root+"/"); rest != name {
+			stripped[rest] = f
+		}
+	}
+	return stripped
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func readYAML(f *zip.File, v any) error {
+	if f == nil {
+		return errors.New("file is missing")
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+func writeZipFile(archive *zip.Writer, name string, r io.Reader) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func writeYAML(archive *zip.Writer, name string, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeZipFile(archive, name, bytes.NewReader(data))
+}
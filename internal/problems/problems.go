@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"diplom/config"
+	"diplom/internal/storage"
 	"errors"
 	"fmt"
+	"io"
+	"regexp"
 	"time"
 
 	"go.uber.org/zap"
@@ -22,6 +25,7 @@ const (
 	MessageCodeCompilationFailed = "Code compilation failed"
 	MessageCodeExecutionFailed   = "Code execution failed"
 	MessageTestCasesFailed       = "Test cases failed"
+	MessagePartiallyAccepted     = "Some test cases failed, partial score awarded"
 	MessageAllTestCasesPassed    = "All test cases passed!"
 )
 
@@ -30,20 +34,94 @@ var (
 	ErrProblemNotFound   = errors.New("problem not found")
 	ErrTestCasesNotFound = errors.New("test cases not found")
 	ErrTestCaseNotFound  = errors.New("test case not found")
+	ErrSubtaskNotFound   = errors.New("subtask not found")
 	ErrCompilationFailed = errors.New("compilation failed")
 	ErrExecutionFailed   = errors.New("execution failed")
 	ErrExecutionTimeout  = errors.New("execution timeout")
+
+	ErrOutputLimitExceeded = errors.New("output limit exceeded")
+	ErrInvalidIOSettings   = errors.New("invalid I/O settings")
+)
+
+// I/O modes of a problem
+const (
+	// IOModeStdio feeds the input to stdin and judges stdout
+	IOModeStdio = "stdio"
+	// IOModeFile places the input into a named file and judges a named output file
+	IOModeFile = "file"
+)
+
+// Default file names used in file I/O mode
+const (
+	DefaultInputFile  = "input.txt"
+	DefaultOutputFile = "output.txt"
 )
 
+var ioFileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]{0,63}$`)
+
+// IOSettings describes how a solution reads the input and writes the output
+type IOSettings struct {
+	Mode       string `json:"io_mode"`
+	InputFile  string `json:"input_file,omitempty"`
+	OutputFile string `json:"output_file,omitempty"`
+}
+
+// Normalize fills in defaults and validates the settings
+func (s IOSettings) Normalize() (IOSettings, error) {
+	switch s.Mode {
+	case "", IOModeStdio:
+		return IOSettings{Mode: IOModeStdio}, nil
+	case IOModeFile:
+	default:
+		return s, ErrInvalidIOSettings
+	}
+
+	if s.InputFile == "" {
+		s.InputFile = DefaultInputFile
+	}
+	if s.OutputFile == "" {
+		s.OutputFile = DefaultOutputFile
+	}
+	if !ioFileNamePattern.MatchString(s.InputFile) || !ioFileNamePattern.MatchString(s.OutputFile) ||
+		s.InputFile == s.OutputFile {
+		return s, ErrInvalidIOSettings
+	}
+	return s, nil
+}
+
 // Problem represents a coding problem entity
 type Problem struct {
-	ID          int              `json:"id"`
-	UUID        string           `json:"uuid"`
-	Name        string           `json:"name"`
-	Difficulty  string           `json:"difficulty"`
-	Description string           `json:"description"`
-	Solved      bool             `json:"solved"`
-	Solution    *ProblemSolution `json:"solution,omitempty"`
+	ID         int    `json:"id"`
+	UUID       string `json:"uuid"`
+	Name       string `json:"name"`
+	Difficulty string `json:"difficulty"`
+	// Rating is the numeric difficulty recomputed from solver data
+	Rating          *float64   `json:"rating,omitempty"`
+	RatingUpdatedAt *time.Time `json:"rating_updated_at,omitempty"`
+	Description     string     `json:"description"` // sanitized HTML rendered from DescriptionSource
+	// DescriptionSource is the Markdown source of the statement
+	DescriptionSource string `json:"description_source,omitempty"`
+	InputFormat       string `json:"input_format,omitempty"`  // sanitized HTML, only in translations
+	OutputFormat      string `json:"output_format,omitempty"` // sanitized HTML, only in translations
+	// Locale is the locale of the statement, Locales lists all available ones
+	Locale  string   `json:"locale,omitempty"`
+	Locales []string `json:"locales,omitempty"`
+	IOSettings
+	Revision  int        `json:"revision"`
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+	OwnerUUID string     `json:"owner_uuid,omitempty"` // empty: only admins may edit
+	// PublishError explains why the last scheduled publication failed
+	PublishError string           `json:"publish_error,omitempty"`
+	Tags         []string         `json:"tags"`
+	Solved       bool             `json:"solved"`
+	MaxScore     float64          `json:"max_score"`
+	BestScore    float64          `json:"best_score"`
+	Subtasks     []Subtask        `json:"subtasks,omitempty"`
+	Solution     *ProblemSolution `json:"solution,omitempty"`
+	// StarterCode maps languages to editor templates, Drafts are the user's autosaved code
+	StarterCode map[string]string `json:"starter_code,omitempty"`
+	Drafts      []Draft           `json:"drafts,omitempty"`
 }
 
 // SolutionRequest contains data needed to process a solution
@@ -51,6 +129,9 @@ type SolutionRequest struct {
 	ProblemUUID string `json:"problem_uuid"`
 	Code        string `json:"code" binding:"required"`
 	Language    string `json:"language" binding:"required"`
+	// ContestID and TeamID are set by the contest service for submissions made within a contest
+	ContestID *int `json:"-"`
+	TeamID    *int `json:"-"`
 }
 
 // SubmitResult contains the outcome of processing a solution
@@ -60,6 +141,7 @@ type SubmitResult struct {
 	ErrorDetails string                 `json:"error_details,omitempty"`
 	FailedTests  []TestCaseResult       `json:"failed_tests,omitempty"`
 	Details      *SolutionResultDetails `json:"details,omitempty"`
+	Score        *SolutionScore         `json:"score,omitempty"`
 }
 
 // SolutionResultDetails contains performance metrics
@@ -75,43 +157,110 @@ type SolutionResultDetails struct {
 
 type ProblemSolution struct {
 	SolutionResultDetails
-	CreatedAt time.Time `json:"created_at"`
-	Code      string    `json:"code"`
-	Language  string    `json:"language"`
-	Status    string    `json:"status"`
+	SolutionScore
+	ProblemRevision int       `json:"problem_revision,omitempty"`
+	ContestID       *int      `json:"contest_id,omitempty"`
+	TeamID          *int      `json:"team_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	Code            string    `json:"code"`
+	Language        string    `json:"language"`
+	Status          string    `json:"status"`
 }
 
-// TestCase represents input/output test data for a problem
+// TestCase represents input/output test data for a problem. Small legacy
+// tests keep their data inline, others reference objects in the test data store.
 type TestCase struct {
-	ID     int    `json:"id"`
-	Input  string `json:"input"`
-	Output string `json:"output"`
+	ID         int    `json:"id"`
+	Input      string `json:"input"`
+	Output     string `json:"output"`
+	InputKey   string `json:"input_key,omitempty"`
+	OutputKey  string `json:"output_key,omitempty"`
+	InputSize  int64  `json:"input_size"`
+	OutputSize int64  `json:"output_size"`
+	SubtaskID  *int   `json:"subtask_id,omitempty"`
 }
 
 // TestCaseResult extends TestCase with actual execution output
 type TestCaseResult struct {
 	TestCase
-	ActualOutput string `json:"actual_output,omitempty"`
+	ActualOutput string  `json:"actual_output,omitempty"`
+	Passed       bool    `json:"-"`
+	Score        float64 `json:"-"` // 0..1, share of the test's points earned
 }
 
 // ProblemRepository defines the data access interface for problems
 type ProblemRepository interface {
 	GetTestCasesByProblemUUID(problemUUID string) ([]TestCase, error)
 	GetProblemByUUID(uuid string, userID string) (*Problem, error)
-	AddProblem(uuid, name, difficulty, description string) error
-	AddTestcase(problemUUID, input, output string) error
+	AddProblem(uuid, authorUUID string, req CreateProblemRequest) error
+	UpdateProblem(uuid, authorUUID string, req UpdateProblemRequest) (int, error)
+	GetProblemRevisions(uuid string) ([]ProblemRevision, error)
+	GetProblemRevision(uuid string, revision int) (ProblemRevision, error)
+	AddTestcase(problemUUID string, testCase TestCase) error
+	SaveTestcases(problemUUID string, testCases []TestCase, replace bool) error
+	GetTestCaseByID(id int) (TestCase, error)
 	GetAllProblems(userID string) ([]Problem, error)
+	ListProblems(userID string, q ProblemListQuery, limit int) ([]ProblemSummary, error)
+	GetAllTags() ([]string, error)
+	SetProblemTags(problemUUID string, tags []string) error
 	DeleteTestcase(id int) error
 	DeleteProblem(uuid string) error
 	SaveSolution(userID, problemUUID string, solution ProblemSolution, isAccepted bool) (int, error)
 	GetSolutionByProblemAndUser(userID, problemUUID string) (ProblemSolution, error)
 	GetSolutionStatistics(problemUUID, userID, language string, userTime float64, userMemory int64) (float64, int64, float64, float64, error)
+	GetSubtasksByProblemUUID(problemUUID string) ([]Subtask, error)
+	AddSubtask(problemUUID string, req CreateSubtaskRequest) (int, error)
+	DeleteSubtask(id int) error
+	AddAuthorSolution(problemUUID, language, code string) (int, error)
+	GetAuthorSolutions(problemUUID string) ([]AuthorSolution, error)
+	SetAuthorSolutionStatus(id int, status string) error
+	DeleteAuthorSolution(id int) error
+	SetProblemStatus(uuid, status string, publishAt *time.Time, publishError string) error
+	GetDueScheduledProblems(now time.Time) ([]string, error)
+	GetProblemStats(problemUUID string) (ProblemStats, error)
+	GetRatingInputs() ([]RatingInput, error)
+	SetProblemRatings(ratings map[string]*float64) error
+	GetEditorial(problemUUID string) (Editorial, error)
+	SetEditorial(problemUUID, source, content string) error
+	DeleteEditorial(problemUUID string) error
+	GetHints(problemUUID string) ([]Hint, error)
+	AddHint(problemUUID string, position int, source, content string) (int, error)
+	DeleteHint(id int) error
+	GetRevealedHints(userID, problemUUID string) ([]Hint, error)
+	RevealNextHint(userID, problemUUID string) (Hint, error)
+	GiveUp(userID, problemUUID string) error
+	HasGivenUp(userID, problemUUID string) (bool, error)
+	GetCollections(userID string) ([]Collection, error)
+	GetCollectionProblems(id int, userID string, includeUnpublished bool) ([]CollectionProblem, error)
+	CreateCollection(req CollectionRequest) (int, error)
+	UpdateCollection(id int, req CollectionRequest) error
+	DeleteCollection(id int) error
+	GetProblemTranslations(problemUUID string) ([]Translation, error)
+	GetTranslatedNames(problemUUIDs []string) (map[string]map[string]string, error)
+	SetProblemTranslation(problemUUID string, t Translation) error
+	DeleteProblemTranslation(problemUUID, locale string) error
+	SaveAttachment(a Attachment) (int, time.Time, error)
+	GetAttachments(problemUUID string) ([]Attachment, error)
+	GetAttachment(problemUUID, name string) (Attachment, error)
+	GetAttachmentsSize(problemUUID, exceptName string) (int64, error)
+	DeleteAttachment(problemUUID, name string) error
+	GetStarterCode(problemUUID string) (map[string]string, error)
+	SetStarterCode(problemUUID, language, code string) error
+	DeleteStarterCode(problemUUID, language string) error
+	GetDrafts(userID, problemUUID string) ([]Draft, error)
+	SaveDraft(userID, problemUUID, language, code string) (time.Time, error)
+	DeleteDraft(userID, problemUUID, language string) error
+	GetUserByUsername(username string) (CoAuthor, error)
+	GetCoAuthors(problemUUID string) ([]CoAuthor, error)
+	AddCoAuthor(problemUUID, userUUID string) error
+	RemoveCoAuthor(problemUUID, userUUID string) error
 }
 
 // ProblemService orchestrates problem-related operations
 type ProblemService struct {
 	ProblemRepo  ProblemRepository
 	DockerClient *DockerClient
+	TestData     *TestData
 	Logger       *zap.Logger
 }
 
@@ -119,23 +268,60 @@ type ProblemService struct {
 type CreateProblemRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Difficulty  string `json:"difficulty" binding:"required"` // e.g.: "easy", "medium", "hard"
-	Description string `json:"description"`
+	Description string `json:"description"`                   // Markdown with $inline$ and $$display$$ math
+	IOSettings
+	Tags []string `json:"tags,omitempty"`
+
+	// DescriptionHTML is filled by RenderDescription and stored alongside the source
+	DescriptionHTML string `json:"-"`
+}
+
+// RenderDescription renders the Markdown description to sanitized HTML
+func (r *CreateProblemRequest) RenderDescription() error {
+	rendered, err := RenderStatement(r.Description)
+	if err != nil {
+		return err
+	}
+	r.DescriptionHTML = rendered
+	return nil
+}
+
+// SetTagsRequest replaces the tags of a problem
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
 }
 
 // CreateTestcaseRequest contains data needed to create a test case
 type CreateTestcaseRequest struct {
-	Input  string `json:"input"`
-	Output string `json:"output"`
+	Input     string `json:"input"`
+	Output    string `json:"output"`
+	SubtaskID *int   `json:"subtask_id"`
+}
+
+// CreateSubtaskRequest contains data needed to create a subtask
+type CreateSubtaskRequest struct {
+	Position    int     `json:"position"`
+	Points      float64 `json:"points" binding:"required,gt=0"`
+	Scoring     string  `json:"scoring"` // "min" or "all", defaults to "min"
+	Description string  `json:"description"`
 }
 
 // NewProblemService creates a new service with default configuration
 func NewProblemService(repo ProblemRepository, logger *zap.Logger) (*ProblemService, error) {
-	return NewProblemServiceWithConfig(repo, logger, config.CFG.Runtime)
+	store, err := storage.NewStoreFromConfig(config.CFG.Storage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create test data store: %w", err)
+	}
+	testData, err := NewTestData(store, config.CFG.Storage.CacheDir, logger.Named("testdata"))
+	if err != nil {
+		return nil, err
+	}
+	return NewProblemServiceWithConfig(repo, logger, config.CFG.Runtime, testData)
 }
 
 // NewProblemServiceWithConfig creates a new service with custom configuration
-func NewProblemServiceWithConfig(repo ProblemRepository, logger *zap.Logger, config config.RuntimeConfig) (*ProblemService, error) {
-	dockerClient, err := NewDockerClient(logger.Named("docker"), config)
+func NewProblemServiceWithConfig(repo ProblemRepository, logger *zap.Logger, config config.RuntimeConfig, testData *TestData) (*ProblemService, error) {
+	dockerClient, err := NewDockerClient(logger.Named("docker"), config, testData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -144,11 +330,35 @@ func NewProblemServiceWithConfig(repo ProblemRepository, logger *zap.Logger, con
 		ProblemRepo:  repo,
 		Logger:       logger.Named("problem"),
 		DockerClient: dockerClient,
+		TestData:     testData,
 	}, nil
 }
 
+// AddTestcase puts the test data into the store and records the test case
+func (s *ProblemService) AddTestcase(ctx context.Context, problemUUID string, input, output io.Reader, subtaskID *int) error {
+	inputKey, inputSize, err := s.TestData.Store(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to store test input: %w", err)
+	}
+	outputKey, outputSize, err := s.TestData.Store(ctx, output)
+	if err != nil {
+		return fmt.Errorf("failed to store test output: %w", err)
+	}
+
+	return s.ProblemRepo.AddTestcase(problemUUID, TestCase{
+		InputKey:   inputKey,
+		OutputKey:  outputKey,
+		InputSize:  inputSize,
+		OutputSize: outputSize,
+		SubtaskID:  subtaskID,
+	})
+}
+
 // ProcessSolution handles code submission and execution
 func (s *ProblemService) ProcessSolution(ctx context.Context, req SolutionRequest, userID string) (*SubmitResult, error) {
+	// Время посылки фиксируется до тестирования: по нему считается штраф в соревнованиях
+	submittedAt := time.Now()
+
 	// Fetch problem
 	problem, err := s.ProblemRepo.GetProblemByUUID(req.ProblemUUID, userID)
 	if err == sql.ErrNoRows {
@@ -167,6 +377,16 @@ func (s *ProblemService) ProcessSolution(ctx context.Context, req SolutionReques
 		return nil, fmt.Errorf("failed to fetch test cases: %w", err)
 	}
 
+	// Fetch test data before taking a container slot
+	if err := s.TestData.Prefetch(ctx, testCases); err != nil {
+		return nil, err
+	}
+
+	subtasks, err := s.ProblemRepo.GetSubtasksByProblemUUID(problem.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subtasks: %w", err)
+	}
+
 	// Create container and process solution
 	containerID, errorDetails, err := s.DockerClient.CreateContainer(ctx, req.Code, req.Language)
 	defer func() {
@@ -190,14 +410,28 @@ func (s *ProblemService) ProcessSolution(ctx context.Context, req SolutionReques
 	}
 
 	// Execute code against test cases
-	passed, failedTests, details, errorDetails, err := s.DockerClient.ExecuteTests(ctx, containerID, req.Language, testCases)
+	results, details, errorDetails, err := s.DockerClient.ExecuteTests(ctx, containerID, req.Language, problem.IOSettings, testCases)
 	if err != nil && !errors.Is(err, ErrExecutionFailed) {
 		return nil, fmt.Errorf("failed to execute code: %w", err)
 	}
 
+	passed := len(results) > 0
+	var failedTests []TestCaseResult
+	for _, r := range results {
+		if !r.Passed {
+			passed = false
+			failedTests = append(failedTests, r)
+		}
+	}
+	score := CalculateScore(subtasks, results)
+
 	problemSolution := ProblemSolution{
 		SolutionResultDetails: details,
-		CreatedAt:             time.Now(),
+		SolutionScore:         score,
+		ProblemRevision:       problem.Revision,
+		ContestID:             req.ContestID,
+		TeamID:                req.TeamID,
+		CreatedAt:             submittedAt,
 		Code:                  req.Code,
 		Language:              req.Language,
 	}
@@ -212,15 +446,21 @@ func (s *ProblemService) ProcessSolution(ctx context.Context, req SolutionReques
 			Status:       StatusFailed,
 			Message:      MessageCodeExecutionFailed,
 			ErrorDetails: errorDetails,
+			Score:        &score,
 		}, ErrExecutionFailed
 	}
 
 	if !passed {
+		message := MessageTestCasesFailed
+		if score.Score > 0 {
+			message = MessagePartiallyAccepted
+		}
 		return &SubmitResult{
 			Status:      StatusFailed,
-			Message:     MessageTestCasesFailed,
+			Message:     message,
 			FailedTests: failedTests,
 			Details:     &details,
+			Score:       &score,
 		}, nil
 	}
 
@@ -228,5 +468,6 @@ func (s *ProblemService) ProcessSolution(ctx context.Context, req SolutionReques
 		Status:  StatusSuccess,
 		Message: MessageAllTestCasesPassed,
 		Details: &details,
+		Score:   &score,
 	}, nil
 }
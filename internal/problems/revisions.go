@@ -0,0 +1,121 @@
+package problems
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrRevisionNotFound = errors.New("problem revision not found")
+
+// ProblemRevision is an immutable snapshot of a problem statement and settings
+type ProblemRevision struct {
+	ProblemUUID string `json:"problem_uuid"`
+	Revision    int    `json:"revision"`
+	Name        string `json:"name"`
+	Difficulty  string `json:"difficulty"`
+	Description string `json:"description"`
+	IOSettings
+	AuthorUUID string    `json:"author_uuid,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UpdateProblemRequest contains the new state of a problem
+type UpdateProblemRequest struct {
+	CreateProblemRequest
+	Comment string `json:"comment"`
+}
+
+// Diff line operations
+const (
+	DiffEqual  = " "
+	DiffInsert = "+"
+	DiffDelete = "-"
+)
+
+// DiffLine is a single line of a field diff
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// FieldDiff lists the line changes of a single problem field
+type FieldDiff struct {
+	Field string     `json:"field"`
+	Lines []DiffLine `json:"lines"`
+}
+
+// RevisionDiff describes changes between two revisions of a problem
+type RevisionDiff struct {
+	From    int         `json:"from"`
+	To      int         `json:"to"`
+	Changes []FieldDiff `json:"changes"`
+}
+
+// DiffRevisions compares every field of two revisions, unchanged fields are omitted
+func DiffRevisions(from, to ProblemRevision) RevisionDiff {
+	diff := RevisionDiff{From: from.Revision, To: to.Revision, Changes: []FieldDiff{}}
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", from.Name, to.Name},
+		{"difficulty", from.Difficulty, to.Difficulty},
+		{"description", from.Description, to.Description},
+		{"io_mode", from.Mode, to.Mode},
+		{"input_file", from.InputFile, to.InputFile},
+		{"output_file", from.OutputFile, to.OutputFile},
+	}
+	for _, f := range fields {
+		if f.old == f.new {
+			continue
+		}
+		diff.Changes = append(diff.Changes, FieldDiff{Field: f.name, Lines: diffLines(f.old, f.new)})
+	}
+	return diff
+}
+
+// diffLines computes a line diff using the longest common subsequence
+func diffLines(oldText, newText string) []DiffLine {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: DiffEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: DiffInsert, Text: b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,126 @@
+package problems
+
+import (
+	"errors"
+	"math"
+)
+
+// Subtask scoring modes
+const (
+	// ScoringMin awards the subtask points multiplied by the lowest test score in the group
+	ScoringMin = "min"
+	// ScoringAll awards the subtask points only when every test in the group is fully passed
+	ScoringAll = "all"
+)
+
+// DefaultMaxScore is awarded for problems without subtasks when all tests pass
+const DefaultMaxScore = 100
+
+var ErrInvalidScoring = errors.New("invalid subtask scoring mode")
+
+// Subtask groups test cases of a problem and assigns them points
+type Subtask struct {
+	ID          int     `json:"id"`
+	ProblemUUID string  `json:"problem_uuid"`
+	Position    int     `json:"position"`
+	Points      float64 `json:"points"`
+	Scoring     string  `json:"scoring"`
+	Description string  `json:"description,omitempty"`
+}
+
+// SubtaskScore is the result of a single subtask in a submission
+type SubtaskScore struct {
+	SubtaskID int     `json:"subtask_id"`
+	Points    float64 `json:"points"`
+	Score     float64 `json:"score"`
+	Passed    int     `json:"passed"`
+	Total     int     `json:"total"`
+}
+
+// SolutionScore is the partial score of a submission
+type SolutionScore struct {
+	Score         float64        `json:"score"`
+	MaxScore      float64        `json:"max_score"`
+	SubtaskScores []SubtaskScore `json:"subtask_scores,omitempty"`
+}
+
+// ValidateScoring normalizes the scoring mode of a subtask
+func ValidateScoring(scoring string) (string, error) {
+	switch scoring {
+	case "":
+		return ScoringMin, nil
+	case ScoringMin, ScoringAll:
+		return scoring, nil
+	default:
+		return "", ErrInvalidScoring
+	}
+}
+
+// MaxScore returns the maximum score achievable on a problem
+func MaxScore(subtasks []Subtask) float64 {
+	if len(subtasks) == 0 {
+		return DefaultMaxScore
+	}
+	var total float64
+	for _, st := range subtasks {
+		total += st.Points
+	}
+	return total
+}
+
+// CalculateScore computes the submission score from per-test results.
+// Without subtasks the problem is all-or-nothing; tests not assigned to
+// any subtask do not affect the score in that case. An empty subtask
+// awards nothing.
+func CalculateScore(subtasks []Subtask, results []TestCaseResult) SolutionScore {
+	if len(subtasks) == 0 {
+		score := SolutionScore{MaxScore: DefaultMaxScore}
+		allPassed := len(results) > 0
+		for _, r := range results {
+			allPassed = allPassed && r.Passed
+		}
+		if allPassed {
+			score.Score = DefaultMaxScore
+		}
+		return score
+	}
+
+	bySubtask := make(map[int][]TestCaseResult)
+	for _, r := range results {
+		if r.SubtaskID != nil {
+			bySubtask[*r.SubtaskID] = append(bySubtask[*r.SubtaskID], r)
+		}
+	}
+
+	score := SolutionScore{MaxScore: MaxScore(subtasks)}
+	for _, st := range subtasks {
+		group := bySubtask[st.ID]
+		result := SubtaskScore{SubtaskID: st.ID, Points: st.Points, Total: len(group)}
+
+		minScore := 1.0
+		for _, r := range group {
+			if r.Passed {
+				result.Passed++
+			}
+			minScore = math.Min(minScore, r.Score)
+		}
+
+		if len(group) > 0 {
+			switch st.Scoring {
+			case ScoringAll:
+				if result.Passed == result.Total {
+					result.Score = st.Points
+				}
+			default:
+				result.Score = st.Points * minScore
+			}
+		}
+		result.Score = math.Round(result.Score*100) / 100
+
+		score.Score += result.Score
+		score.SubtaskScores = append(score.SubtaskScores, result)
+	}
+	score.Score = math.Round(score.Score*100) / 100
+
+	return score
+}
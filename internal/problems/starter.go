@@ -0,0 +1,67 @@
+package problems
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxDraftSize limits an autosaved draft
+const MaxDraftSize = 64 << 10
+
+var (
+	ErrStarterCodeNotFound = errors.New("starter code not found")
+	ErrDraftNotFound       = errors.New("draft not found")
+	ErrDraftTooLarge       = errors.New("draft exceeds 64 KB")
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+)
+
+// Draft is the autosaved code of a user for a problem in one language
+type Draft struct {
+	Language  string    `json:"language"`
+	Code      string    `json:"code"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetStarterCodeRequest sets the template of a problem for one language
+type SetStarterCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// SaveDraftRequest autosaves the editor contents
+type SaveDraftRequest struct {
+	Code string `json:"code"`
+}
+
+// GetStarterCode returns the template for every supported language: the one
+// set by admins or the language default for the problem's IO mode
+func (s *ProblemService) GetStarterCode(problem *Problem) (map[string]string, error) {
+	custom, err := s.ProblemRepo.GetStarterCode(problem.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	starter := make(map[string]string, len(SupportedLanguages))
+	for _, language := range SupportedLanguages {
+		if code, ok := custom[language]; ok {
+			starter[language] = code
+			continue
+		}
+		handler, err := GetLanguageHandler(language)
+		if err != nil {
+			return nil, err
+		}
+		starter[language] = handler.GetStarterCode(problem.IOSettings)
+	}
+	return starter, nil
+}
+
+// SaveDraft validates and stores the draft
+func (s *ProblemService) SaveDraft(userID, problemUUID, language, code string) (time.Time, error) {
+	if _, err := GetLanguageHandler(language); err != nil {
+		return time.Time{}, ErrUnsupportedLanguage
+	}
+	if len(code) > MaxDraftSize {
+		return time.Time{}, ErrDraftTooLarge
+	}
+	return s.ProblemRepo.SaveDraft(userID, problemUUID, language, code)
+}
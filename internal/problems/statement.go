@@ -0,0 +1,155 @@
+package problems
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Условия задач пишутся в Markdown (GFM) с формулами $...$ и $$...$$.
+// Сырой HTML в исходнике допускается ради старых условий, но весь результат
+// проходит через allowlist: скрипты, обработчики событий и javascript: ссылки
+// вырезаются, формулы превращаются в MathML на сервере.
+
+var statementMarkdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, mathExtension{}),
+	goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+)
+
+var statementPolicy = newStatementPolicy()
+
+func newStatementPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|right|center)$`)).OnElements("td", "th")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input")
+
+	p.AllowNoAttrs().OnElements("math", "semantics", "annotation", "mrow", "mi", "mn", "mo", "mtext", "mspace",
+		"msub", "msup", "msubsup", "munder", "mover", "munderover", "mfrac", "msqrt", "mroot", "merror")
+	p.AllowAttrs("xmlns").Matching(regexp.MustCompile(`^http://www\.w3\.org/1998/Math/MathML$`)).OnElements("math")
+	p.AllowAttrs("display").Matching(regexp.MustCompile(`^(block|inline)$`)).OnElements("math")
+	p.AllowAttrs("encoding").Matching(regexp.MustCompile(`^application/x-tex$`)).OnElements("annotation")
+	p.AllowAttrs("mathvariant").Matching(regexp.MustCompile(`^[a-z-]+$`)).OnElements("mi")
+	p.AllowAttrs("width").Matching(regexp.MustCompile(`^-?[0-9.]+em$`)).OnElements("mspace")
+	p.AllowAttrs("stretchy", "fence", "largeop", "movablelimits").Matching(regexp.MustCompile(`^(true|false)$`)).OnElements("mo")
+	p.AllowAttrs("lspace", "rspace").Matching(regexp.MustCompile(`^-?[0-9.]+em$`)).OnElements("mo")
+	p.AllowAttrs("accent").Matching(regexp.MustCompile(`^(true|false)$`)).OnElements("mover")
+	p.AllowAttrs("linethickness").Matching(regexp.MustCompile(`^0$`)).OnElements("mfrac")
+	return p
+}
+
+// RenderStatement converts a Markdown statement to sanitized HTML
+func RenderStatement(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := statementMarkdown.Convert([]byte(source), &buf); err != nil {
+		return "", fmt.Errorf("failed to render statement: %w", err)
+	}
+	return statementPolicy.Sanitize(buf.String()), nil
+}
+
+// MathNode is an inline or display formula of a statement
+type MathNode struct {
+	ast.BaseInline
+	TeX     string
+	Display bool
+}
+
+var KindMath = ast.NewNodeKind("Math")
+
+func (n *MathNode) Kind() ast.NodeKind { return KindMath }
+
+func (n *MathNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": n.TeX}, nil)
+}
+
+type mathExtension struct{}
+
+func (mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(util.Prioritized(mathParser{}, 150)))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(mathRenderer{}, 150)))
+}
+
+// mathParser recognizes $inline$ and $$display$$ formulas. Like in Pandoc, an inline
+// formula must not start or end with a space, so "$5 and $10" stays plain text.
+type mathParser struct{}
+
+func (mathParser) Trigger() []byte { return []byte{'$'} }
+
+func (mathParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if len(line) > 1 && line[1] == '$' {
+		return parseDisplayMath(block)
+	}
+
+	for i := 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '$':
+			tex := line[1:i]
+			if len(tex) == 0 || isMathSpace(tex[0]) || isMathSpace(tex[len(tex)-1]) {
+				return nil
+			}
+			// "$5$10" и т.п.: за закрывающим долларом не должна идти цифра
+			if i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9' {
+				return nil
+			}
+			block.Advance(i + 1)
+			return &MathNode{TeX: string(tex)}
+		}
+	}
+	return nil
+}
+
+// parseDisplayMath reads $$...$$ which may span several lines of a paragraph
+func parseDisplayMath(block text.Reader) ast.Node {
+	l, pos := block.Position()
+	block.Advance(2)
+
+	var tex bytes.Buffer
+	for {
+		line, _ := block.PeekLine()
+		if line == nil {
+			block.SetPosition(l, pos)
+			return nil
+		}
+		if end := bytes.Index(line, []byte("$$")); end >= 0 {
+			tex.Write(line[:end])
+			block.Advance(end + 2)
+			formula := bytes.TrimSpace(tex.Bytes())
+			if len(formula) == 0 {
+				block.SetPosition(l, pos)
+				return nil
+			}
+			return &MathNode{TeX: string(formula), Display: true}
+		}
+		tex.Write(line)
+		block.AdvanceLine()
+	}
+}
+
+func isMathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+type mathRenderer struct{}
+
+func (mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMath, func(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			n := node.(*MathNode)
+			_, _ = w.WriteString(TeXToMathML(n.TeX, n.Display))
+		}
+		return ast.WalkSkipChildren, nil
+	})
+}
@@ -0,0 +1,150 @@
+package problems
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Verdicts in the statistics: partial solutions are rejected ones with a positive score
+const (
+	VerdictAccepted = "accepted"
+	VerdictPartial  = "partial"
+	VerdictRejected = "rejected"
+)
+
+const (
+	// HistogramBuckets is the number of equal-width execution time buckets per language
+	HistogramBuckets = 10
+
+	// ratingInterval is how often difficulty ratings are recomputed
+	ratingInterval = time.Hour
+	// minRatingAttempters is the number of users required before a rating is shown
+	minRatingAttempters = 5
+	// ratingPriorWeight is how many virtual attempters the manual difficulty is worth
+	ratingPriorWeight = 10
+)
+
+// ProblemStats aggregates all submissions of a problem
+type ProblemStats struct {
+	Attempts         int             `json:"attempts"`
+	UniqueAttempters int             `json:"unique_attempters"`
+	UniqueSolvers    int             `json:"unique_solvers"`
+	AcceptanceRate   float64         `json:"acceptance_rate"`
+	Verdicts         map[string]int  `json:"verdicts"`
+	Languages        []LanguageStats `json:"languages"`
+	Rating           *float64        `json:"rating,omitempty"`
+	RatingUpdatedAt  *time.Time      `json:"rating_updated_at,omitempty"`
+	Difficulty       string          `json:"difficulty"`
+}
+
+// LanguageStats describes submissions in one language. The histogram is built
+// from execution times of accepted submissions.
+type LanguageStats struct {
+	Language      string            `json:"language"`
+	Attempts      int               `json:"attempts"`
+	Accepted      int               `json:"accepted"`
+	TimeHistogram []HistogramBucket `json:"time_histogram"`
+}
+
+// HistogramBucket counts accepted submissions with execution time in [FromMS, ToMS)
+type HistogramBucket struct {
+	FromMS float64 `json:"from_ms"`
+	ToMS   float64 `json:"to_ms"`
+	Count  int     `json:"count"`
+}
+
+// RatingInput is the solver data a difficulty rating is computed from
+type RatingInput struct {
+	ProblemUUID string
+	Difficulty  string
+	Attempters  int
+	Solvers     int
+	// AvgAttemptsToSolve is the mean number of submissions up to the first accepted one
+	AvgAttemptsToSolve float64
+}
+
+// GetStats collects the statistics of the problem
+func (s *ProblemService) GetStats(problem *Problem) (ProblemStats, error) {
+	stats, err := s.ProblemRepo.GetProblemStats(problem.UUID)
+	if err != nil {
+		return ProblemStats{}, err
+	}
+	if stats.Attempts > 0 {
+		stats.AcceptanceRate = math.Round(float64(stats.Verdicts[VerdictAccepted])/float64(stats.Attempts)*10000) / 100
+	}
+	stats.Difficulty = problem.Difficulty
+	stats.Rating = problem.Rating
+	stats.RatingUpdatedAt = problem.RatingUpdatedAt
+	return stats, nil
+}
+
+// priorSolveRate is the expected share of solvers for the manual difficulty label
+func priorSolveRate(difficulty string) float64 {
+	switch difficulty {
+	case "easy":
+		return 0.8
+	case "hard":
+		return 0.25
+	default:
+		return 0.5
+	}
+}
+
+// CalculateRating turns solver data into a numeric difficulty on a 800–3500 scale.
+// The solve rate among attempters is smoothed towards the manual label, so problems
+// with few attempts stay close to it; every doubling of attempts needed to get
+// accepted adds 200 points. Returns false while there are too few attempters.
+func CalculateRating(in RatingInput) (float64, bool) {
+	if in.Attempters < minRatingAttempters {
+		return 0, false
+	}
+
+	solveRate := (float64(in.Solvers) + ratingPriorWeight*priorSolveRate(in.Difficulty)) /
+		float64(in.Attempters+ratingPriorWeight)
+	rating := 800 + 2000*(1-solveRate)
+	if in.AvgAttemptsToSolve > 1 {
+		rating += 200 * math.Log2(in.AvgAttemptsToSolve)
+	}
+
+	rating = math.Max(800, math.Min(3500, rating))
+	return math.Round(rating/10) * 10, true
+}
+
+// RunRatingUpdater periodically recomputes difficulty ratings until ctx is cancelled
+func (s *ProblemService) RunRatingUpdater(ctx context.Context) {
+	ticker := time.NewTicker(ratingInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.UpdateRatings(); err != nil {
+			s.Logger.Error("failed to update difficulty ratings", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// UpdateRatings recomputes the difficulty rating of every problem
+func (s *ProblemService) UpdateRatings() error {
+	inputs, err := s.ProblemRepo.GetRatingInputs()
+	if err != nil {
+		return err
+	}
+
+	ratings := make(map[string]*float64, len(inputs))
+	for _, in := range inputs {
+		if rating, ok := CalculateRating(in); ok {
+			ratings[in.ProblemUUID] = &rating
+		} else {
+			ratings[in.ProblemUUID] = nil
+		}
+	}
+	return s.ProblemRepo.SetProblemRatings(ratings)
+}
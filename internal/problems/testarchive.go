@@ -0,0 +1,186 @@
+package problems
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxTestArchiveSize limits the size of an uploaded test archive
+	MaxTestArchiveSize = 512 << 20
+	// MaxTestFileSize limits the uncompressed size of a single test file
+	MaxTestFileSize = 64 << 20
+)
+
+var ErrInvalidTestArchive = errors.New("invalid test archive")
+
+// TestFileError describes a problem with a single file of a test archive
+type TestFileError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// TestArchiveError is returned when some files of the archive are invalid
+type TestArchiveError struct {
+	Files []TestFileError
+}
+
+func (e *TestArchiveError) Error() string {
+	return fmt.Sprintf("%s: %d invalid files", ErrInvalidTestArchive, len(e.Files))
+}
+
+func (e *TestArchiveError) Unwrap() error { return ErrInvalidTestArchive }
+
+type archiveTest struct {
+	name          string
+	input, output *zip.File
+}
+
+// parseTestArchive pairs NN.in/NN.out and NN/NN.a files of the archive.
+// Directories are ignored, tests are ordered by their numeric names.
+func parseTestArchive(r io.ReaderAt, size int64) ([]archiveTest, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTestArchive, err)
+	}
+
+	var fileErrors []TestFileError
+	tests := map[string]*archiveTest{}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		base := path.Base(f.Name)
+		if strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		var stem string
+		var isInput bool
+		switch ext := path.Ext(base); ext {
+		case ".in":
+			stem, isInput = strings.TrimSuffix(base, ext), true
+		case ".out", ".a", ".ans":
+			stem = strings.TrimSuffix(base, ext)
+		case "":
+			if _, err := strconv.Atoi(base); err == nil {
+				stem, isInput = base, true
+			}
+		}
+		if stem == "" {
+			fileErrors = append(fileErrors, TestFileError{File: f.Name, Error: "unrecognized file name, expected NN.in/NN.out or NN/NN.a"})
+			continue
+		}
+		if f.UncompressedSize64 > MaxTestFileSize {
+			fileErrors = append(fileErrors, TestFileError{File: f.Name, Error: fmt.Sprintf("file is larger than %d MB", MaxTestFileSize>>20)})
+			continue
+		}
+
+		tc, ok := tests[stem]
+		if !ok {
+			tc = &archiveTest{name: stem}
+			tests[stem] = tc
+		}
+		slot := &tc.output
+		if isInput {
+			slot = &tc.input
+		}
+		if *slot != nil {
+			fileErrors = append(fileErrors, TestFileError{File: f.Name, Error: "duplicate of " + (*slot).Name})
+			continue
+		}
+		*slot = f
+	}
+
+	ordered := make([]archiveTest, 0, len(tests))
+	for _, tc := range tests {
+		switch {
+		case tc.input == nil:
+			fileErrors = append(fileErrors, TestFileError{File: tc.output.Name, Error: "input file is missing"})
+		case tc.output == nil:
+			fileErrors = append(fileErrors, TestFileError{File: tc.input.Name, Error: "output file is missing"})
+		default:
+			ordered = append(ordered, *tc)
+		}
+	}
+
+	if len(fileErrors) > 0 {
+		sort.Slice(fileErrors, func(i, j int) bool { return fileErrors[i].File < fileErrors[j].File })
+		return nil, &TestArchiveError{Files: fileErrors}
+	}
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("%w: archive has no tests", ErrInvalidTestArchive)
+	}
+
+	sort.Slice(ordered, func(i, j int) bool { return testNameLess(ordered[i].name, ordered[j].name) })
+	return ordered, nil
+}
+
+// testNameLess orders numeric names by value so that 2 goes before 10
+func testNameLess(a, b string) bool {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil && x != y:
+		return x < y
+	case errA == nil && errB != nil:
+		return true
+	case errA != nil && errB == nil:
+		return false
+	}
+	return a < b
+}
+
+// UploadTestArchive stores all tests of the archive and either appends them to the
+// problem or replaces its test set. Test cases are saved in a single transaction.
+func (s *ProblemService) UploadTestArchive(ctx context.Context, problemUUID string, r io.ReaderAt, size int64, subtaskID *int, replace bool) (int, error) {
+	tests, err := parseTestArchive(r, size)
+	if err != nil {
+		return 0, err
+	}
+
+	testCases := make([]TestCase, 0, len(tests))
+	for _, tc := range tests {
+		testCase := TestCase{SubtaskID: subtaskID}
+		if testCase.InputKey, testCase.InputSize, err = s.storeZipFile(ctx, tc.input); err != nil {
+			return 0, err
+		}
+		if testCase.OutputKey, testCase.OutputSize, err = s.storeZipFile(ctx, tc.output); err != nil {
+			return 0, err
+		}
+		testCases = append(testCases, testCase)
+	}
+
+	if err := s.ProblemRepo.SaveTestcases(problemUUID, testCases, replace); err != nil {
+		return 0, fmt.Errorf("failed to save test cases: %w", err)
+	}
+	return len(testCases), nil
+}
+
+func (s *ProblemService) storeZipFile(ctx context.Context, f *zip.File) (string, int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", 0, &TestArchiveError{Files: []TestFileError{{File: f.Name, Error: err.Error()}}}
+	}
+	defer rc.Close()
+
+	key, size, err := s.TestData.Store(ctx, io.LimitReader(rc, MaxTestFileSize+1))
+	if err != nil {
+		// Повреждённый архив отдаём как ошибку конкретного файла
+		if errors.Is(err, zip.ErrChecksum) || errors.Is(err, zip.ErrFormat) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return "", 0, &TestArchiveError{Files: []TestFileError{{File: f.Name, Error: err.Error()}}}
+		}
+		return "", 0, fmt.Errorf("failed to store %s: %w", f.Name, err)
+	}
+	if size > MaxTestFileSize {
+		return "", 0, &TestArchiveError{Files: []TestFileError{{File: f.Name, Error: fmt.Sprintf("file is larger than %d MB", MaxTestFileSize>>20)}}}
+	}
+	return key, size, nil
+}
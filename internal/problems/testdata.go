@@ -0,0 +1,158 @@
+package problems
+
+import (
+	"context"
+	"diplom/internal/storage"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// TestData stores test inputs and expected outputs outside the database and
+// keeps a checksummed local copy of every object used by the judge
+type TestData struct {
+	store    storage.Store
+	cacheDir string
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	loading map[string]*sync.Mutex
+}
+
+// NewTestData creates the judge host cache in cacheDir
+func NewTestData(store storage.Store, cacheDir string, logger *zap.Logger) (*TestData, error) {
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.TempDir(), "diplom-testdata-cache")
+	}
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create test data cache: %w", err)
+	}
+	return &TestData{
+		store:    store,
+		cacheDir: cacheDir,
+		logger:   logger,
+		loading:  make(map[string]*sync.Mutex),
+	}, nil
+}
+
+// Store saves the content and returns its key and size
+func (t *TestData) Store(ctx context.Context, r io.Reader) (string, int64, error) {
+	return t.store.Put(ctx, r)
+}
+
+// Delete removes an object from the store and the local cache
+func (t *TestData) Delete(ctx context.Context, key string) error {
+	if key == "" {
+		return nil
+	}
+	os.Remove(filepath.Join(t.cacheDir, key))
+	return t.store.Delete(ctx, key)
+}
+
+// Prefetch makes sure every object of the test cases is cached locally
+func (t *TestData) Prefetch(ctx context.Context, testCases []TestCase) error {
+	for _, tc := range testCases {
+		for _, key := range []string{tc.InputKey, tc.OutputKey} {
+			if key == "" {
+				continue
+			}
+			if _, err := t.cachedPath(ctx, key); err != nil {
+				return fmt.Errorf("failed to fetch test data of test case %d: %w", tc.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// OpenInput returns the input of the test case, streamed from the cache when stored externally
+func (t *TestData) OpenInput(ctx context.Context, tc TestCase) (io.ReadCloser, error) {
+	if tc.InputKey == "" {
+		return io.NopCloser(strings.NewReader(tc.Input)), nil
+	}
+	path, err := t.cachedPath(ctx, tc.InputKey)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// ExpectedOutput returns the expected output of the test case
+func (t *TestData) ExpectedOutput(ctx context.Context, tc TestCase) (string, error) {
+	if tc.OutputKey == "" {
+		return tc.Output, nil
+	}
+	path, err := t.cachedPath(ctx, tc.OutputKey)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// Open returns a reader for any stored object
+func (t *TestData) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := t.cachedPath(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// cachedPath downloads the object into the cache once and verifies its checksum
+func (t *TestData) cachedPath(ctx context.Context, key string) (string, error) {
+	if err := storage.ValidateKey(key); err != nil {
+		return "", err
+	}
+	path := filepath.Join(t.cacheDir, key)
+
+	// Serialize downloads of the same object between concurrent submissions
+	t.mu.Lock()
+	lock, ok := t.loading[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		t.loading[key] = lock
+	}
+	t.mu.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	src, err := t.store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(t.cacheDir, key+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := storage.VerifyFile(tmp.Name(), key); err != nil {
+		t.logger.Error("corrupted test data object", zap.String("key", key), zap.Error(err))
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", err
+	}
+
+	t.logger.Debug("test data cached", zap.String("key", key))
+	return path, nil
+}
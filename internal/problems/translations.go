@@ -0,0 +1,214 @@
+package problems
+
+import (
+	"diplom/config"
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fallbackLocale is used when the config does not set default_locale
+const fallbackLocale = "ru"
+
+var (
+	ErrInvalidLocale       = errors.New("locale must be a language code like \"en\" or \"pt-BR\"")
+	ErrTranslationNotFound = errors.New("translation not found")
+)
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// Translation is the statement of a problem in one locale. The base name and
+// description of a problem are in the default locale and serve as the fallback;
+// a translation in the default locale overrides them and may add the formats.
+type Translation struct {
+	Locale             string    `json:"locale"`
+	Name               string    `json:"name"`
+	Description        string    `json:"description"` // sanitized HTML
+	DescriptionSource  string    `json:"description_source,omitempty"`
+	InputFormat        string    `json:"input_format,omitempty"` // sanitized HTML
+	InputFormatSource  string    `json:"input_format_source,omitempty"`
+	OutputFormat       string    `json:"output_format,omitempty"` // sanitized HTML
+	OutputFormatSource string    `json:"output_format_source,omitempty"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// SetTranslationRequest creates or replaces a translation, texts are Markdown
+type SetTranslationRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Description  string `json:"description"`
+	InputFormat  string `json:"input_format"`
+	OutputFormat string `json:"output_format"`
+}
+
+// DefaultLocale returns the locale of the base statements
+func DefaultLocale() string {
+	if config.CFG.DefaultLocale != "" {
+		return config.CFG.DefaultLocale
+	}
+	return fallbackLocale
+}
+
+// NormalizeLocale converts "en_us" or "EN-us" to "en-US" and validates it
+func NormalizeLocale(locale string) (string, error) {
+	lang, region, _ := strings.Cut(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	locale = strings.ToLower(lang)
+	if region != "" {
+		locale += "-" + strings.ToUpper(region)
+	}
+	if !localePattern.MatchString(locale) {
+		return "", ErrInvalidLocale
+	}
+	return locale, nil
+}
+
+// PreferredLocales builds the locale preference list from an explicit query
+// parameter and the Accept-Language header. The query parameter wins; regional
+// variants are followed by their base language ("en-US" → "en"). Invalid
+// entries are skipped.
+func PreferredLocales(query, acceptLanguage string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var entries []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if locale, err := NormalizeLocale(tag); err == nil && q > 0 {
+			entries = append(entries, weighted{locale, q})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].q > entries[j].q })
+
+	var locales []string
+	add := func(locale string) {
+		if !slices.Contains(locales, locale) {
+			locales = append(locales, locale)
+		}
+		if base, _, ok := strings.Cut(locale, "-"); ok && !slices.Contains(locales, base) {
+			locales = append(locales, base)
+		}
+	}
+	if locale, err := NormalizeLocale(query); err == nil {
+		add(locale)
+	}
+	for _, e := range entries {
+		add(e.locale)
+	}
+	return locales
+}
+
+// pickLocale returns the first preferred locale that is available, the default one otherwise
+func pickLocale(preferred []string, available func(string) bool) string {
+	defaultLocale := DefaultLocale()
+	for _, locale := range preferred {
+		if locale == defaultLocale || available(locale) {
+			return locale
+		}
+	}
+	return defaultLocale
+}
+
+// Localize replaces the statement of the problem with the best matching translation
+func (s *ProblemService) Localize(problem *Problem, preferred []string) error {
+	translations, err := s.ProblemRepo.GetProblemTranslations(problem.UUID)
+	if err != nil {
+		return err
+	}
+
+	byLocale := make(map[string]Translation, len(translations))
+	problem.Locales = []string{DefaultLocale()}
+	for _, t := range translations {
+		byLocale[t.Locale] = t
+		if !slices.Contains(problem.Locales, t.Locale) {
+			problem.Locales = append(problem.Locales, t.Locale)
+		}
+	}
+
+	problem.Locale = pickLocale(preferred, func(locale string) bool {
+		_, ok := byLocale[locale]
+		return ok
+	})
+	if t, ok := byLocale[problem.Locale]; ok {
+		problem.Name = t.Name
+		problem.Description = t.Description
+		problem.DescriptionSource = t.DescriptionSource
+		problem.InputFormat = t.InputFormat
+		problem.OutputFormat = t.OutputFormat
+	}
+	return nil
+}
+
+// localizeSummaries replaces problem names in the list with the best matching translations
+func (s *ProblemService) localizeSummaries(summaries []ProblemSummary, preferred []string) error {
+	if len(summaries) == 0 {
+		return nil
+	}
+	uuids := make([]string, len(summaries))
+	for i, p := range summaries {
+		uuids[i] = p.UUID
+	}
+
+	names, err := s.ProblemRepo.GetTranslatedNames(uuids)
+	if err != nil {
+		return err
+	}
+	for i := range summaries {
+		translated := names[summaries[i].UUID]
+		summaries[i].Locale = pickLocale(preferred, func(locale string) bool {
+			_, ok := translated[locale]
+			return ok
+		})
+		if name, ok := translated[summaries[i].Locale]; ok {
+			summaries[i].Name = name
+		}
+	}
+	return nil
+}
+
+// SetTranslation renders and stores the translation of the problem
+func (s *ProblemService) SetTranslation(problemUUID, locale string, req SetTranslationRequest) (Translation, error) {
+	locale, err := NormalizeLocale(locale)
+	if err != nil {
+		return Translation{}, err
+	}
+	if _, err := s.ProblemRepo.GetProblemByUUID(problemUUID, ""); err != nil {
+		return Translation{}, err
+	}
+
+	t := Translation{
+		Locale:             locale,
+		Name:               strings.TrimSpace(req.Name),
+		DescriptionSource:  req.Description,
+		InputFormatSource:  req.InputFormat,
+		OutputFormatSource: req.OutputFormat,
+	}
+	for _, field := range []struct {
+		source string
+		html   *string
+	}{
+		{t.DescriptionSource, &t.Description},
+		{t.InputFormatSource, &t.InputFormat},
+		{t.OutputFormatSource, &t.OutputFormat},
+	} {
+		if *field.html, err = RenderStatement(field.source); err != nil {
+			return Translation{}, err
+		}
+	}
+
+	if err := s.ProblemRepo.SetProblemTranslation(problemUUID, t); err != nil {
+		return Translation{}, err
+	}
+	return t, nil
+}
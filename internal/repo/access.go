@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/auth"
+	"diplom/internal/problems"
+	"diplom/internal/user"
+	"errors"
+	"fmt"
+)
+
+// GetProblemAccess reports whether the user owns or co-authors the problem
+func (sr *PGClient) GetProblemAccess(problemUUID, userID string) (auth.ProblemAccess, error) {
+	query := `
+		SELECT 
+			p.owner_uuid IS NOT DISTINCT FROM $2,
+			EXISTS (
+				SELECT 1 
+				FROM problem_coauthors pc 
+				WHERE pc.problem_uuid = p.uuid AND pc.user_uuid = $2
+			)
+		FROM problems p 
+		WHERE p.uuid = $1
+	`
+	var access auth.ProblemAccess
+	err := sr.db.QueryRow(query, problemUUID, userID).Scan(&access.Owner, &access.CoAuthor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return access, auth.ErrResourceNotFound
+	}
+	return access, err
+}
+
+// resourceTables maps resources of ProblemAccessMiddleware to their tables
+var resourceTables = map[string]string{
+	"testcase":        "testcases",
+	"subtask":         "subtasks",
+	"hint":            "hints",
+	"author solution": "author_solutions",
+}
+
+// GetResourceProblem returns the UUID of the problem the resource belongs to
+func (sr *PGClient) GetResourceProblem(resource string, id int) (string, error) {
+	table, ok := resourceTables[resource]
+	if !ok {
+		return "", fmt.Errorf("unknown resource: %s", resource)
+	}
+
+	var problemUUID string
+	err := sr.db.QueryRow("SELECT problem_uuid FROM "+table+" WHERE id = $1", id).Scan(&problemUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", auth.ErrResourceNotFound
+	}
+	return problemUUID, err
+}
+
+func (sr *PGClient) GetUserByUsername(username string) (problems.CoAuthor, error) {
+	var u problems.CoAuthor
+	err := sr.db.QueryRow("SELECT uuid, username, role FROM users WHERE username = $1", username).Scan(&u.UUID, &u.Username, &u.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, problems.ErrUserNotFound
+	}
+	return u, err
+}
+
+func (sr *PGClient) GetCoAuthors(problemUUID string) ([]problems.CoAuthor, error) {
+	query := `
+		SELECT u.uuid, u.username, u.role 
+		FROM problem_coauthors pc 
+		JOIN users u ON u.uuid = pc.user_uuid 
+		WHERE pc.problem_uuid = $1 
+		ORDER BY u.username
+	`
+	rows, err := sr.db.Query(query, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coAuthors := []problems.CoAuthor{}
+	for rows.Next() {
+		var u problems.CoAuthor
+		if err := rows.Scan(&u.UUID, &u.Username, &u.Role); err != nil {
+			return nil, err
+		}
+		coAuthors = append(coAuthors, u)
+	}
+	return coAuthors, rows.Err()
+}
+
+func (sr *PGClient) AddCoAuthor(problemUUID, userUUID string) error {
+	_, err := sr.db.Exec(
+		"INSERT INTO problem_coauthors (problem_uuid, user_uuid) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		problemUUID, userUUID,
+	)
+	return err
+}
+
+func (sr *PGClient) RemoveCoAuthor(problemUUID, userUUID string) error {
+	result, err := sr.db.Exec("DELETE FROM problem_coauthors WHERE problem_uuid = $1 AND user_uuid = $2", problemUUID, userUUID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrCoAuthorNotFound
+	}
+
+	return nil
+}
+
+// ListUsers returns all users ordered by username
+func (sr *PGClient) ListUsers() ([]user.User, error) {
+	rows, err := sr.db.Query("SELECT uuid, username, role, created_at FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []user.User{}
+	for rows.Next() {
+		var u user.User
+		if err := rows.Scan(&u.UUID, &u.Username, &u.Role, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (sr *PGClient) SetUserRole(userUUID, role string) error {
+	result, err := sr.db.Exec("UPDATE users SET role = $2 WHERE uuid = $1", userUUID, role)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return user.ErrUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/problems"
+	"errors"
+	"time"
+)
+
+// SaveAttachment stores the attachment, replacing the one with the same name
+func (sr *PGClient) SaveAttachment(a problems.Attachment) (int, time.Time, error) {
+	query := `
+		INSERT INTO problem_attachments (problem_uuid, name, content_type, size, object_key) 
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (problem_uuid, name) DO UPDATE 
+		SET content_type = EXCLUDED.content_type, 
+		    size = EXCLUDED.size, 
+		    object_key = EXCLUDED.object_key, 
+		    created_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at
+	`
+	var id int
+	var createdAt time.Time
+	err := sr.db.QueryRow(query, a.ProblemUUID, a.Name, a.ContentType, a.Size, a.Key).Scan(&id, &createdAt)
+	return id, createdAt, err
+}
+
+func (sr *PGClient) GetAttachments(problemUUID string) ([]problems.Attachment, error) {
+	query := `
+		SELECT id, problem_uuid, name, content_type, size, object_key, created_at 
+		FROM problem_attachments 
+		WHERE problem_uuid = $1 
+		ORDER BY name
+	`
+	rows, err := sr.db.Query(query, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []problems.Attachment{}
+	for rows.Next() {
+		var a problems.Attachment
+		if err := rows.Scan(&a.ID, &a.ProblemUUID, &a.Name, &a.ContentType, &a.Size, &a.Key, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (sr *PGClient) GetAttachment(problemUUID, name string) (problems.Attachment, error) {
+	query := `
+		SELECT id, problem_uuid, name, content_type, size, object_key, created_at 
+		FROM problem_attachments 
+		WHERE problem_uuid = $1 AND name = $2
+	`
+	var a problems.Attachment
+	err := sr.db.QueryRow(query, problemUUID, name).Scan(&a.ID, &a.ProblemUUID, &a.Name, &a.ContentType, &a.Size, &a.Key, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, problems.ErrAttachmentNotFound
+	}
+	return a, err
+}
+
+// GetAttachmentsSize sums the sizes of the problem attachments except the one being replaced
+func (sr *PGClient) GetAttachmentsSize(problemUUID, exceptName string) (int64, error) {
+	var size int64
+	err := sr.db.QueryRow(
+		"SELECT COALESCE(SUM(size), 0) FROM problem_attachments WHERE problem_uuid = $1 AND name <> $2",
+		problemUUID, exceptName,
+	).Scan(&size)
+	return size, err
+}
+
+// DeleteAttachment removes the record only: objects are content-addressed and may be shared
+func (sr *PGClient) DeleteAttachment(problemUUID, name string) error {
+	result, err := sr.db.Exec("DELETE FROM problem_attachments WHERE problem_uuid = $1 AND name = $2", problemUUID, name)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrAttachmentNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/problems"
+)
+
+// AddAuthorSolution stores a reference solution of the problem
+func (sr *PGClient) AddAuthorSolution(problemUUID, language, code string) (int, error) {
+	query := `
+		INSERT INTO author_solutions (problem_uuid, language, code) 
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	var id int
+	err := sr.db.QueryRow(query, problemUUID, language, code).Scan(&id)
+	return id, err
+}
+
+// GetAuthorSolutions returns reference solutions of the problem in the order they were added
+func (sr *PGClient) GetAuthorSolutions(problemUUID string) ([]problems.AuthorSolution, error) {
+	query := `
+		SELECT id, problem_uuid, language, code, status, checked_at 
+		FROM author_solutions 
+		WHERE problem_uuid = $1 
+		ORDER BY id
+	`
+	rows, err := sr.db.Query(query, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	solutions := []problems.AuthorSolution{}
+	for rows.Next() {
+		var sol problems.AuthorSolution
+		var checkedAt sql.NullTime
+		if err := rows.Scan(&sol.ID, &sol.ProblemUUID, &sol.Language, &sol.Code, &sol.Status, &checkedAt); err != nil {
+			return nil, err
+		}
+		if checkedAt.Valid {
+			sol.CheckedAt = &checkedAt.Time
+		}
+		solutions = append(solutions, sol)
+	}
+	return solutions, rows.Err()
+}
+
+// SetAuthorSolutionStatus records the verdict of a publishing check
+func (sr *PGClient) SetAuthorSolutionStatus(id int, status string) error {
+	_, err := sr.db.Exec("UPDATE author_solutions SET status = $2, checked_at = NOW() WHERE id = $1", id, status)
+	return err
+}
+
+func (sr *PGClient) DeleteAuthorSolution(id int) error {
+	result, err := sr.db.Exec("DELETE FROM author_solutions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrAuthorSolutionNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/problems"
+
+	"github.com/lib/pq"
+)
+
+// GetCollections returns all collections with prerequisites and the number of
+// published problems solved by the user
+func (sr *PGClient) GetCollections(userID string) ([]problems.Collection, error) {
+	query := `
+		SELECT 
+			c.id, 
+			c.title, 
+			c.description, 
+			c.position,
+			ARRAY(
+				SELECT cp.prerequisite_id 
+				FROM collection_prerequisites cp 
+				WHERE cp.collection_id = c.id 
+				ORDER BY cp.prerequisite_id
+			),
+			COUNT(p.uuid),
+			COUNT(p.uuid) FILTER (WHERE EXISTS (
+				SELECT 1 
+				FROM solutions s 
+				WHERE s.problem_uuid = p.uuid 
+				  AND s.user_uuid = $1 
+				  AND s.status = 'accepted'
+			))
+		FROM collections c 
+		LEFT JOIN collection_problems cpr ON cpr.collection_id = c.id 
+		LEFT JOIN problems p ON p.uuid = cpr.problem_uuid AND p.status = 'published' 
+		GROUP BY c.id 
+		ORDER BY c.position, c.id
+	`
+	rows, err := sr.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []problems.Collection{}
+	for rows.Next() {
+		var c problems.Collection
+		var prerequisites pq.Int64Array
+		err := rows.Scan(&c.ID, &c.Title, &c.Description, &c.Position, &prerequisites, &c.Total, &c.Solved)
+		if err != nil {
+			return nil, err
+		}
+		c.Prerequisites = make([]int, len(prerequisites))
+		for i, id := range prerequisites {
+			c.Prerequisites[i] = int(id)
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+// GetCollectionProblems returns problems of the collection in order
+func (sr *PGClient) GetCollectionProblems(id int, userID string, includeUnpublished bool) ([]problems.CollectionProblem, error) {
+	query := `
+		SELECT 
+			p.uuid, 
+			p.name, 
+			p.difficulty, 
+			p.status,
+			EXISTS (
+				SELECT 1 
+				FROM solutions s 
+				WHERE s.problem_uuid = p.uuid 
+				  AND s.user_uuid = $2 
+				  AND s.status = 'accepted'
+			)
+		FROM collection_problems cp 
+		JOIN problems p ON p.uuid = cp.problem_uuid 
+		WHERE cp.collection_id = $1 
+		  AND ($3 OR p.status = 'published') 
+		ORDER BY cp.position
+	`
+	rows, err := sr.db.Query(query, id, userID, includeUnpublished)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []problems.CollectionProblem{}
+	for rows.Next() {
+		var p problems.CollectionProblem
+		if err := rows.Scan(&p.UUID, &p.Name, &p.Difficulty, &p.Status, &p.Solved); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+func (sr *PGClient) CreateCollection(req problems.CollectionRequest) (int, error) {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(
+		"INSERT INTO collections (title, description, position) VALUES ($1, $2, $3) RETURNING id",
+		req.Title, req.Description, req.Position,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := saveCollectionContents(tx, id, req); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// UpdateCollection replaces the collection together with its problems and prerequisites
+func (sr *PGClient) UpdateCollection(id int, req problems.CollectionRequest) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE collections SET title = $2, description = $3, position = $4 WHERE id = $1",
+		id, req.Title, req.Description, req.Position,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return problems.ErrCollectionNotFound
+	}
+
+	if _, err := tx.Exec("DELETE FROM collection_problems WHERE collection_id = $1", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM collection_prerequisites WHERE collection_id = $1", id); err != nil {
+		return err
+	}
+	if err := saveCollectionContents(tx, id, req); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveCollectionContents(tx *sql.Tx, id int, req problems.CollectionRequest) error {
+	for i, problemUUID := range req.Problems {
+		_, err := tx.Exec(
+			"INSERT INTO collection_problems (collection_id, problem_uuid, position) VALUES ($1, $2, $3)",
+			id, problemUUID, i,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	for _, prerequisite := range req.Prerequisites {
+		_, err := tx.Exec(
+			"INSERT INTO collection_prerequisites (collection_id, prerequisite_id) VALUES ($1, $2)",
+			id, prerequisite,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sr *PGClient) DeleteCollection(id int) error {
+	result, err := sr.db.Exec("DELETE FROM collections WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrCollectionNotFound
+	}
+
+	return nil
+}
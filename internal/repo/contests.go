@@ -0,0 +1,414 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/contests"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// contestSelect also finds the participation of the user $1: their own
+// registration or, in team contests, the one of their team
+const contestSelect = `
+	SELECT
+		c.id,
+		c.title,
+		c.description,
+		c.start_time,
+		c.end_time,
+		c.freeze_time,
+		c.team_mode,
+		(SELECT COUNT(*) FROM contest_participants cp WHERE cp.contest_id = c.id AND cp.virtual_start IS NULL),
+		me.id IS NOT NULL AND me.virtual_start IS NULL,
+		me.virtual_start,
+		me.team_id
+	FROM contests c
+	LEFT JOIN LATERAL (
+		SELECT cp.id, cp.team_id, cp.virtual_start
+		FROM contest_participants cp
+		WHERE cp.contest_id = c.id
+		  AND (cp.user_uuid = $1 OR cp.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_uuid = $1))
+		ORDER BY cp.virtual_start NULLS FIRST
+		LIMIT 1
+	) me ON TRUE`
+
+func scanContest(row interface{ Scan(...any) error }) (contests.Contest, error) {
+	var c contests.Contest
+	err := row.Scan(&c.ID, &c.Title, &c.Description, &c.StartTime, &c.EndTime, &c.FreezeTime, &c.TeamMode,
+		&c.Participants, &c.Registered, &c.VirtualStart, &c.TeamID)
+	return c, err
+}
+
+// GetContests returns all contests, newest first, with the user's registration
+func (sr *PGClient) GetContests(userID string) ([]contests.Contest, error) {
+	rows, err := sr.db.Query(contestSelect+" ORDER BY c.start_time DESC, c.id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []contests.Contest{}
+	for rows.Next() {
+		c, err := scanContest(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+func (sr *PGClient) GetContest(id int, userID string) (contests.Contest, error) {
+	row := sr.db.QueryRow(contestSelect+" WHERE c.id = $2", userID, id)
+	c, err := scanContest(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return contests.Contest{}, contests.ErrContestNotFound
+	}
+	return c, err
+}
+
+// GetContestProblems returns problems of the contest in order
+func (sr *PGClient) GetContestProblems(id int) ([]contests.ContestProblem, error) {
+	query := `
+		SELECT p.uuid, p.name, cp.position
+		FROM contest_problems cp
+		JOIN problems p ON p.uuid = cp.problem_uuid
+		WHERE cp.contest_id = $1
+		ORDER BY cp.position
+	`
+	rows, err := sr.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []contests.ContestProblem{}
+	for rows.Next() {
+		var p contests.ContestProblem
+		if err := rows.Scan(&p.UUID, &p.Name, &p.Position); err != nil {
+			return nil, err
+		}
+		p.Label = contests.ProblemLabel(p.Position)
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+func (sr *PGClient) CreateContest(authorUUID string, req contests.ContestRequest) (int, error) {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(
+		"INSERT INTO contests (title, description, start_time, end_time, freeze_time, team_mode, created_by) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		req.Title, req.Description, req.StartTime, req.EndTime, req.FreezeTime, req.TeamMode, nullIfEmpty(authorUUID),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := saveContestProblems(tx, id, req.Problems); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// UpdateContest replaces the contest together with its problems. Submissions
+// and registrations are kept.
+func (sr *PGClient) UpdateContest(id int, req contests.ContestRequest) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"UPDATE contests SET title = $2, description = $3, start_time = $4, end_time = $5, freeze_time = $6, team_mode = $7 WHERE id = $1",
+		id, req.Title, req.Description, req.StartTime, req.EndTime, req.FreezeTime, req.TeamMode,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return contests.ErrContestNotFound
+	}
+
+	if _, err := tx.Exec("DELETE FROM contest_problems WHERE contest_id = $1", id); err != nil {
+		return err
+	}
+	if err := saveContestProblems(tx, id, req.Problems); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveContestProblems(tx *sql.Tx, id int, problemUUIDs []string) error {
+	for i, problemUUID := range problemUUIDs {
+		_, err := tx.Exec(
+			"INSERT INTO contest_problems (contest_id, problem_uuid, position) VALUES ($1, $2, $3)",
+			id, problemUUID, i,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sr *PGClient) DeleteContest(id int) error {
+	result, err := sr.db.Exec("DELETE FROM contests WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return contests.ErrContestNotFound
+	}
+
+	return nil
+}
+
+// RegisterParticipant registers the user, or the team if teamID is set
+func (sr *PGClient) RegisterParticipant(id int, userID string, teamID *int) error {
+	_, err := sr.db.Exec(
+		"INSERT INTO contest_participants (contest_id, user_uuid, team_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		id, participantUser(userID, teamID), teamID,
+	)
+	return err
+}
+
+func (sr *PGClient) StartVirtualRun(id int, userID string, teamID *int, start time.Time) error {
+	result, err := sr.db.Exec(
+		"INSERT INTO contest_participants (contest_id, user_uuid, team_id, virtual_start) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING",
+		id, participantUser(userID, teamID), teamID, start,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return contests.ErrVirtualRunExists
+	}
+	return nil
+}
+
+// participantUser is the user_uuid of a participant: teams are registered without one
+func participantUser(userID string, teamID *int) any {
+	if teamID != nil {
+		return nil
+	}
+	return userID
+}
+
+func (sr *PGClient) IsTeamMember(teamID int, userID string) (bool, error) {
+	var member bool
+	err := sr.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM team_members WHERE team_id = $1 AND user_uuid = $2)",
+		teamID, userID,
+	).Scan(&member)
+	return member, err
+}
+
+// IsTeamMemberRegistered reports whether a member of the team already takes part
+// in the contest on their own or with another team
+func (sr *PGClient) IsTeamMemberRegistered(id, teamID int) (bool, error) {
+	var registered bool
+	err := sr.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM contest_participants cp
+			JOIN team_members member ON member.team_id = $2
+			WHERE cp.contest_id = $1
+			  AND (cp.user_uuid = member.user_uuid
+			    OR cp.team_id IN (SELECT tm.team_id FROM team_members tm WHERE tm.user_uuid = member.user_uuid))
+		)
+	`, id, teamID).Scan(&registered)
+	return registered, err
+}
+
+// GetParticipants returns official and virtual participants in the order of registration
+func (sr *PGClient) GetParticipants(id int) ([]contests.Participant, error) {
+	query := `
+		SELECT COALESCE(cp.user_uuid, ''), COALESCE(u.username, ''), cp.team_id, COALESCE(t.name, ''), cp.registered_at, cp.virtual_start
+		FROM contest_participants cp
+		LEFT JOIN users u ON u.uuid = cp.user_uuid
+		LEFT JOIN teams t ON t.id = cp.team_id
+		WHERE cp.contest_id = $1
+		ORDER BY cp.registered_at, cp.id
+	`
+	rows, err := sr.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []contests.Participant{}
+	for rows.Next() {
+		var p contests.Participant
+		if err := rows.Scan(&p.UserUUID, &p.Username, &p.TeamID, &p.TeamName, &p.RegisteredAt, &p.VirtualStart); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+// GetContestSubmissions returns judged submissions of the contest, official and virtual, in time order
+func (sr *PGClient) GetContestSubmissions(id int) ([]contests.Submission, error) {
+	query := `
+		SELECT id, user_uuid, team_id, problem_uuid, status = 'accepted', created_at
+		FROM solutions
+		WHERE contest_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := sr.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []contests.Submission{}
+	for rows.Next() {
+		var s contests.Submission
+		if err := rows.Scan(&s.ID, &s.UserUUID, &s.TeamID, &s.ProblemUUID, &s.Accepted, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
+
+// GetRevealedSubmissions returns IDs of frozen submissions already revealed by the resolver
+func (sr *PGClient) GetRevealedSubmissions(id int) (map[int]bool, error) {
+	rows, err := sr.db.Query("SELECT solution_id FROM contest_reveals WHERE contest_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revealed := make(map[int]bool)
+	for rows.Next() {
+		var solutionID int
+		if err := rows.Scan(&solutionID); err != nil {
+			return nil, err
+		}
+		revealed[solutionID] = true
+	}
+	return revealed, rows.Err()
+}
+
+func (sr *PGClient) RevealSubmissions(id int, solutionIDs []int) error {
+	_, err := sr.db.Exec(`
+		INSERT INTO contest_reveals (contest_id, solution_id)
+		SELECT $1, unnest($2::int[])
+		ON CONFLICT DO NOTHING
+	`, id, pq.Array(solutionIDs))
+	return err
+}
+
+const clarificationSelect = `
+	SELECT
+		cl.id,
+		cl.contest_id,
+		cl.problem_uuid,
+		cp.position,
+		cl.user_uuid,
+		COALESCE(u.username, ''),
+		cl.question,
+		cl.answer,
+		cl.public,
+		cl.created_at,
+		cl.answered_at
+	FROM contest_clarifications cl
+	LEFT JOIN contest_problems cp ON cp.contest_id = cl.contest_id AND cp.problem_uuid = cl.problem_uuid
+	LEFT JOIN users u ON u.uuid = cl.user_uuid`
+
+func scanClarification(row interface{ Scan(...any) error }) (contests.Clarification, error) {
+	var c contests.Clarification
+	var position sql.NullInt64
+	err := row.Scan(&c.ID, &c.ContestID, &c.ProblemUUID, &position, &c.UserUUID, &c.Username,
+		&c.Question, &c.Answer, &c.Public, &c.CreatedAt, &c.AnsweredAt)
+	if position.Valid {
+		c.Label = contests.ProblemLabel(int(position.Int64))
+	}
+	return c, err
+}
+
+// GetClarifications returns public clarifications and the user's own questions,
+// or all clarifications of the contest, newest first
+func (sr *PGClient) GetClarifications(id int, userID string, all bool) ([]contests.Clarification, error) {
+	rows, err := sr.db.Query(
+		clarificationSelect+" WHERE cl.contest_id = $1 AND ($3 OR cl.public OR cl.user_uuid = $2) ORDER BY cl.created_at DESC, cl.id DESC",
+		id, userID, all,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []contests.Clarification{}
+	for rows.Next() {
+		c, err := scanClarification(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	return result, rows.Err()
+}
+
+func (sr *PGClient) GetClarification(clarificationID int) (contests.Clarification, error) {
+	c, err := scanClarification(sr.db.QueryRow(clarificationSelect+" WHERE cl.id = $1", clarificationID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return contests.Clarification{}, contests.ErrClarificationNotFound
+	}
+	return c, err
+}
+
+func (sr *PGClient) SaveClarification(c contests.Clarification) (int, error) {
+	var id int
+	err := sr.db.QueryRow(`
+		INSERT INTO contest_clarifications (contest_id, problem_uuid, user_uuid, question, answer, public, answered_at, answered_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, c.ContestID, c.ProblemUUID, c.UserUUID, c.Question, c.Answer, c.Public, c.AnsweredAt, c.AnsweredBy).Scan(&id)
+	return id, err
+}
+
+// AnswerClarification sets or replaces the answer to a question
+func (sr *PGClient) AnswerClarification(clarificationID int, judgeUUID, answer string, public bool) error {
+	result, err := sr.db.Exec(`
+		UPDATE contest_clarifications
+		SET answer = $2, public = $3, answered_by = $4, answered_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_uuid IS NOT NULL
+	`, clarificationID, answer, public, nullIfEmpty(judgeUUID))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return contests.ErrClarificationNotFound
+	}
+	return nil
+}
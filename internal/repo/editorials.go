@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/problems"
+	"errors"
+)
+
+func (sr *PGClient) GetEditorial(problemUUID string) (problems.Editorial, error) {
+	query := `
+		SELECT problem_uuid, content, content_source, updated_at 
+		FROM editorials 
+		WHERE problem_uuid = $1
+	`
+	var editorial problems.Editorial
+	err := sr.db.QueryRow(query, problemUUID).Scan(
+		&editorial.ProblemUUID,
+		&editorial.Content,
+		&editorial.Source,
+		&editorial.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return editorial, problems.ErrEditorialNotFound
+	}
+	return editorial, err
+}
+
+// SetEditorial creates or replaces the editorial of the problem
+func (sr *PGClient) SetEditorial(problemUUID, source, content string) error {
+	query := `
+		INSERT INTO editorials (problem_uuid, content, content_source) 
+		VALUES ($1, $2, $3)
+		ON CONFLICT (problem_uuid) DO UPDATE 
+		SET content = EXCLUDED.content, content_source = EXCLUDED.content_source, updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := sr.db.Exec(query, problemUUID, content, source)
+	return err
+}
+
+func (sr *PGClient) DeleteEditorial(problemUUID string) error {
+	result, err := sr.db.Exec("DELETE FROM editorials WHERE problem_uuid = $1", problemUUID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrEditorialNotFound
+	}
+
+	return nil
+}
+
+// GetHints returns all hints of the problem in reveal order
+func (sr *PGClient) GetHints(problemUUID string) ([]problems.Hint, error) {
+	query := `
+		SELECT id, position, content, content_source 
+		FROM hints 
+		WHERE problem_uuid = $1 
+		ORDER BY position, id
+	`
+	rows, err := sr.db.Query(query, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hints := []problems.Hint{}
+	for rows.Next() {
+		var hint problems.Hint
+		if err := rows.Scan(&hint.ID, &hint.Position, &hint.Content, &hint.Source); err != nil {
+			return nil, err
+		}
+		hints = append(hints, hint)
+	}
+	return hints, rows.Err()
+}
+
+func (sr *PGClient) AddHint(problemUUID string, position int, source, content string) (int, error) {
+	query := `
+		INSERT INTO hints (problem_uuid, position, content, content_source) 
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	var id int
+	err := sr.db.QueryRow(query, problemUUID, position, content, source).Scan(&id)
+	return id, err
+}
+
+func (sr *PGClient) DeleteHint(id int) error {
+	result, err := sr.db.Exec("DELETE FROM hints WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrHintNotFound
+	}
+
+	return nil
+}
+
+// GetRevealedHints returns hints the user has opened, without their Markdown source
+func (sr *PGClient) GetRevealedHints(userID, problemUUID string) ([]problems.Hint, error) {
+	query := `
+		SELECT h.id, h.position, h.content 
+		FROM hints h 
+		JOIN hint_reveals r ON r.hint_id = h.id AND r.user_uuid = $1 
+		WHERE h.problem_uuid = $2 
+		ORDER BY h.position, h.id
+	`
+	rows, err := sr.db.Query(query, userID, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hints := []problems.Hint{}
+	for rows.Next() {
+		var hint problems.Hint
+		if err := rows.Scan(&hint.ID, &hint.Position, &hint.Content); err != nil {
+			return nil, err
+		}
+		hints = append(hints, hint)
+	}
+	return hints, rows.Err()
+}
+
+// RevealNextHint records the first hint the user has not seen yet
+func (sr *PGClient) RevealNextHint(userID, problemUUID string) (problems.Hint, error) {
+	query := `
+		WITH next AS (
+			SELECT h.id, h.position, h.content 
+			FROM hints h 
+			WHERE h.problem_uuid = $2 
+			  AND NOT EXISTS (
+			      SELECT 1 FROM hint_reveals r WHERE r.hint_id = h.id AND r.user_uuid = $1
+			  )
+			ORDER BY h.position, h.id 
+			LIMIT 1
+		), revealed AS (
+			INSERT INTO hint_reveals (user_uuid, hint_id) 
+			SELECT $1, id FROM next 
+			ON CONFLICT DO NOTHING
+		)
+		SELECT id, position, content FROM next
+	`
+	var hint problems.Hint
+	err := sr.db.QueryRow(query, userID, problemUUID).Scan(&hint.ID, &hint.Position, &hint.Content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return hint, problems.ErrNoMoreHints
+	}
+	return hint, err
+}
+
+// GiveUp records that the user gave up on the problem, which unlocks the editorial
+func (sr *PGClient) GiveUp(userID, problemUUID string) error {
+	query := `
+		INSERT INTO give_ups (user_uuid, problem_uuid) 
+		VALUES ($1, $2) 
+		ON CONFLICT DO NOTHING
+	`
+	_, err := sr.db.Exec(query, userID, problemUUID)
+	return err
+}
+
+func (sr *PGClient) HasGivenUp(userID, problemUUID string) (bool, error) {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM give_ups WHERE user_uuid = $1 AND problem_uuid = $2)"
+	err := sr.db.QueryRow(query, userID, problemUUID).Scan(&exists)
+	return exists, err
+}
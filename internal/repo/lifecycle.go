@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"diplom/internal/problems"
+	"time"
+)
+
+// SetProblemStatus changes the lifecycle status and the scheduled publication of the problem
+func (sr *PGClient) SetProblemStatus(uuid, status string, publishAt *time.Time, publishError string) error {
+	query := `
+		UPDATE problems 
+		SET status = $2, publish_at = $3, publish_error = $4 
+		WHERE uuid = $1
+	`
+	result, err := sr.db.Exec(query, uuid, status, publishAt, publishError)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return problems.ErrProblemNotFound
+	}
+	return nil
+}
+
+// GetDueScheduledProblems returns drafts whose scheduled publication time has come
+func (sr *PGClient) GetDueScheduledProblems(now time.Time) ([]string, error) {
+	query := `
+		SELECT uuid 
+		FROM problems 
+		WHERE status = 'draft' AND publish_at IS NOT NULL AND publish_at <= $1 
+		ORDER BY publish_at
+	`
+	rows, err := sr.db.Query(query, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var uuids []string
+	for rows.Next() {
+		var uuid string
+		if err := rows.Scan(&uuid); err != nil {
+			return nil, err
+		}
+		uuids = append(uuids, uuid)
+	}
+	return uuids, rows.Err()
+}
@@ -0,0 +1,209 @@
+package repo
+
+import (
+	"diplom/internal/problems"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ListProblems returns problem summaries matching the query, at most limit rows
+func (sr *PGClient) ListProblems(userID string, q problems.ProblemListQuery, limit int) ([]problems.ProblemSummary, error) {
+	args := []any{userID}
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	var filters []string
+	if len(q.Difficulties) > 0 {
+		filters = append(filters, "p.difficulty::text = ANY("+arg(pq.Array(q.Difficulties))+")")
+	}
+	if len(q.Tags) > 0 {
+		filters = append(filters, `p.uuid IN (
+                SELECT pt.problem_uuid 
+                FROM problem_tags pt 
+                JOIN tags t ON t.id = pt.tag_id 
+                WHERE t.name = ANY(`+arg(pq.Array(q.Tags))+`)
+                GROUP BY pt.problem_uuid 
+                HAVING COUNT(DISTINCT t.id) = `+arg(len(q.Tags))+`
+            )`)
+	}
+	if len(q.ProblemStatuses) > 0 {
+		filters = append(filters, "p.status::text = ANY("+arg(pq.Array(q.ProblemStatuses))+")")
+	}
+	if q.EditorUUID != "" {
+		editor := arg(q.EditorUUID)
+		filters = append(filters, `(p.status = 'published' OR p.owner_uuid = `+editor+` OR EXISTS (
+                SELECT 1 
+                FROM problem_coauthors pc 
+                WHERE pc.problem_uuid = p.uuid AND pc.user_uuid = `+editor+`
+            ))`)
+	}
+	if q.Search != "" {
+		filters = append(filters, "p.search_vector @@ websearch_to_tsquery('simple', "+arg(q.Search)+")")
+	}
+	where := ""
+	if len(filters) > 0 {
+		where = "WHERE " + strings.Join(filters, " AND ")
+	}
+
+	var outer []string
+	switch q.Status {
+	case problems.FilterSolved:
+		outer = append(outer, "solved")
+	case problems.FilterUnsolved:
+		outer = append(outer, "NOT solved")
+	}
+
+	var orderBy string
+	switch q.Sort {
+	case problems.SortByNewest:
+		orderBy = "created_at DESC, id DESC"
+		if q.Cursor != nil {
+			outer = append(outer, "(created_at, id) < ("+arg(q.Cursor.CreatedAt)+", "+arg(q.Cursor.ID)+")")
+		}
+	case problems.SortByAcceptance:
+		orderBy = "acceptance_rate DESC, id DESC"
+		if q.Cursor != nil {
+			outer = append(outer, "(acceptance_rate, id) < ("+arg(q.Cursor.Acceptance)+", "+arg(q.Cursor.ID)+")")
+		}
+	default:
+		orderBy = "id"
+		if q.Cursor != nil {
+			outer = append(outer, "id > "+arg(q.Cursor.ID))
+		}
+	}
+	outerWhere := ""
+	if len(outer) > 0 {
+		outerWhere = "WHERE " + strings.Join(outer, " AND ")
+	}
+
+	query := `
+    SELECT id, uuid, name, difficulty, rating, tags, solved, max_score, best_score, acceptance_rate, status, created_at
+    FROM (
+        SELECT 
+            p.id, 
+            p.uuid, 
+            p.name, 
+            p.difficulty, 
+            p.rating,
+            p.created_at,
+            p.status,
+            ARRAY(
+                SELECT t.name 
+                FROM problem_tags pt 
+                JOIN tags t ON t.id = pt.tag_id 
+                WHERE pt.problem_uuid = p.uuid 
+                ORDER BY t.name
+            ) AS tags,
+            EXISTS (
+                SELECT 1 
+                FROM solutions s 
+                WHERE s.problem_uuid = p.uuid 
+                  AND s.user_uuid = $1
+                  AND s.status = 'accepted'
+            ) AS solved,
+            COALESCE((
+                SELECT SUM(st.points) 
+                FROM subtasks st 
+                WHERE st.problem_uuid = p.uuid
+            ), 100) AS max_score,
+            COALESCE((
+                SELECT MAX(s.score) 
+                FROM solutions s 
+                WHERE s.problem_uuid = p.uuid 
+                  AND s.user_uuid = $1
+            ), 0) AS best_score,
+            COALESCE((
+                SELECT ROUND((COUNT(*) FILTER (WHERE s.status = 'accepted')::numeric / NULLIF(COUNT(*), 0)) * 100, 2)::float
+                FROM solutions s 
+                WHERE s.problem_uuid = p.uuid
+            ), 0) AS acceptance_rate
+        FROM 
+            problems p
+        ` + where + `
+    ) list
+    ` + outerWhere + `
+    ORDER BY ` + orderBy + `
+    LIMIT ` + arg(limit)
+
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []problems.ProblemSummary{}
+	for rows.Next() {
+		var p problems.ProblemSummary
+		if err := rows.Scan(
+			&p.ID,
+			&p.UUID,
+			&p.Name,
+			&p.Difficulty,
+			&p.Rating,
+			pq.Array(&p.Tags),
+			&p.Solved,
+			&p.MaxScore,
+			&p.BestScore,
+			&p.AcceptanceRate,
+			&p.Status,
+			&p.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, p)
+	}
+	return summaries, rows.Err()
+}
+
+// GetAllTags returns names of all tags in alphabetical order
+func (sr *PGClient) GetAllTags() ([]string, error) {
+	rows, err := sr.db.Query("SELECT name FROM tags ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// SetProblemTags replaces the tags of the problem, creating missing tags
+func (sr *PGClient) SetProblemTags(problemUUID string, tags []string) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM problem_tags WHERE problem_uuid = $1", problemUUID); err != nil {
+		return err
+	}
+
+	if len(tags) > 0 {
+		if _, err := tx.Exec(
+			"INSERT INTO tags (name) SELECT unnest($1::varchar[]) ON CONFLICT (name) DO NOTHING",
+			pq.Array(tags),
+		); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO problem_tags (problem_uuid, tag_id) 
+			SELECT $1, id FROM tags WHERE name = ANY($2)
+		`, problemUUID, pq.Array(tags)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
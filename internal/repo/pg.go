@@ -5,10 +5,12 @@ import (
 	"diplom/internal/auth"
 	"diplom/internal/problems"
 	"diplom/pkg/dbconnect"
+	"encoding/json"
 	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -25,34 +27,162 @@ func InitPG() (*PGClient, error) {
 
 }
 
-// AddSession добавляет новую сессию или обновляет существующую, устанавливая token и обновляя created_at.
-func (sr *PGClient) AddSession(sess *auth.Session) error {
-	query := `
-        INSERT INTO sessions (username, token, created_at) 
-        VALUES ($1, $2, CURRENT_TIMESTAMP)
-        ON CONFLICT (username) DO UPDATE 
-            SET token = EXCLUDED.token,
-                created_at = CURRENT_TIMESTAMP
-    `
-	_, err := sr.db.Exec(query, sess.Username, sess.Token)
-	return err
+const sessionSelect = `
+	SELECT s.uuid, s.user_uuid, u.username, u.role, s.user_agent, s.ip, s.created_at, s.last_used_at, s.expires_at
+	FROM sessions s
+	JOIN users u ON u.uuid = s.user_uuid`
+
+func scanSession(row interface{ Scan(...any) error }) (auth.Session, error) {
+	var sess auth.Session
+	err := row.Scan(&sess.ID, &sess.UserUUID, &sess.Username, &sess.Role, &sess.UserAgent, &sess.IP,
+		&sess.CreatedAt, &sess.LastUsedAt, &sess.ExpiresAt)
+	return sess, err
 }
 
-// DeleteSessionByToken удаляет сессию по токену.
-func (sr *PGClient) DeleteSessionByToken(token string) error {
-	query := "DELETE FROM sessions WHERE token = $1"
-	_, err := sr.db.Exec(query, token)
-	return err
+// CreateSession сохраняет новую сессию с её первым refresh token и удаляет истёкшие сессии пользователя.
+func (sr *PGClient) CreateSession(sess *auth.Session, refreshHash string) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM sessions WHERE user_uuid = $1 AND expires_at < $2", sess.UserUUID, sess.CreatedAt); err != nil {
+		return err
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO sessions (uuid, user_uuid, user_agent, ip, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $5, $6)
+		RETURNING id
+	`, sess.ID, sess.UserUUID, sess.UserAgent, sess.IP, sess.CreatedAt, sess.ExpiresAt).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)", refreshHash, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSession получает сессию по её идентификатору.
+func (sr *PGClient) GetSession(id string) (*auth.Session, error) {
+	sess, err := scanSession(sr.db.QueryRow(sessionSelect+" WHERE s.uuid = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &sess, nil
 }
 
-// GetSessionByToken получает сессию по токену.
-func (sr *PGClient) GetSessionByToken(token string) (*auth.Session, error) {
-	query := "SELECT username, token FROM sessions WHERE token = $1 LIMIT 1"
-	row := sr.db.QueryRow(query, token)
+// GetUserSessions возвращает сессии пользователя, последние использованные первыми.
+func (sr *PGClient) GetUserSessions(userID string) ([]auth.Session, error) {
+	rows, err := sr.db.Query(sessionSelect+" WHERE s.user_uuid = $1 ORDER BY s.last_used_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	var sess auth.Session
-	err := row.Scan(&sess.Username, &sess.Token)
-	return &sess, err
+	result := []auth.Session{}
+	for rows.Next() {
+		sess, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sess)
+	}
+	return result, rows.Err()
+}
+
+// UseRefreshToken помечает refresh token использованным и возвращает его сессию.
+// Для уже использованного токена возвращается auth.ErrRefreshTokenReused вместе с сессией.
+func (sr *PGClient) UseRefreshToken(hash string) (string, error) {
+	var sessionID string
+	err := sr.db.QueryRow(`
+		UPDATE refresh_tokens rt
+		SET used_at = CURRENT_TIMESTAMP
+		FROM sessions s
+		WHERE rt.token_hash = $1 AND rt.used_at IS NULL AND s.id = rt.session_id
+		RETURNING s.uuid
+	`, hash).Scan(&sessionID)
+	if err == nil {
+		return sessionID, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	err = sr.db.QueryRow(`
+		SELECT s.uuid
+		FROM refresh_tokens rt
+		JOIN sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1
+	`, hash).Scan(&sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", auth.ErrInvalidToken
+	} else if err != nil {
+		return "", err
+	}
+	return sessionID, auth.ErrRefreshTokenReused
+}
+
+// RotateRefreshToken добавляет сессии новый refresh token и продлевает её.
+func (sr *PGClient) RotateRefreshToken(sessionID, hash string, client auth.ClientInfo, expiresAt time.Time) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		UPDATE sessions
+		SET user_agent = $2, ip = $3, last_used_at = CURRENT_TIMESTAMP, expires_at = $4
+		WHERE uuid = $1
+		RETURNING id
+	`, sessionID, client.UserAgent, client.IP, expiresAt).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.ErrSessionNotFound
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)", hash, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteSession удаляет сессию вместе с её refresh token.
+func (sr *PGClient) DeleteSession(id string) error {
+	result, err := sr.db.Exec("DELETE FROM sessions WHERE uuid = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return auth.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// DeleteUserSessions удаляет все сессии пользователя и возвращает их число.
+func (sr *PGClient) DeleteUserSessions(userID string) (int, error) {
+	result, err := sr.db.Exec("DELETE FROM sessions WHERE user_uuid = $1", userID)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	return int(rowsAffected), err
 }
 
 // GetUserByUsername получает пользователя по имени пользователя.
@@ -94,14 +224,43 @@ func (sr *PGClient) GetProblemByUUID(uuid string, userID string) (*problems.Prob
             p.uuid, 
             p.name, 
             p.difficulty, 
+            p.rating,
+            p.rating_updated_at,
             p.description,
+            p.description_source,
+            p.io_mode,
+            COALESCE(p.input_file, ''),
+            COALESCE(p.output_file, ''),
+            p.current_revision,
+            p.status,
+            p.publish_at,
+            p.publish_error,
+            COALESCE(p.owner_uuid, ''),
+            ARRAY(
+                SELECT t.name 
+                FROM problem_tags pt 
+                JOIN tags t ON t.id = pt.tag_id 
+                WHERE pt.problem_uuid = p.uuid 
+                ORDER BY t.name
+            ) AS tags,
             EXISTS (
                 SELECT 1 
                 FROM solutions s 
                 WHERE s.problem_uuid = p.uuid 
                   AND s.user_uuid = $1
                   AND s.status = 'accepted'
-            ) AS solved
+            ) AS solved,
+            COALESCE((
+                SELECT SUM(st.points) 
+                FROM subtasks st 
+                WHERE st.problem_uuid = p.uuid
+            ), 100) AS max_score,
+            COALESCE((
+                SELECT MAX(s.score) 
+                FROM solutions s 
+                WHERE s.problem_uuid = p.uuid 
+                  AND s.user_uuid = $1
+            ), 0) AS best_score
         FROM 
             problems p
         WHERE 
@@ -110,14 +269,37 @@ func (sr *PGClient) GetProblemByUUID(uuid string, userID string) (*problems.Prob
     `
 	row := sr.db.QueryRow(query, userID, uuid)
 	var problem problems.Problem
+	var publishAt, ratingUpdatedAt sql.NullTime
+	var rating sql.NullFloat64
 	err := row.Scan(
 		&problem.ID,
 		&problem.UUID,
 		&problem.Name,
 		&problem.Difficulty,
+		&rating,
+		&ratingUpdatedAt,
 		&problem.Description,
+		&problem.DescriptionSource,
+		&problem.Mode,
+		&problem.InputFile,
+		&problem.OutputFile,
+		&problem.Revision,
+		&problem.Status,
+		&publishAt,
+		&problem.PublishError,
+		&problem.OwnerUUID,
+		pq.Array(&problem.Tags),
 		&problem.Solved,
+		&problem.MaxScore,
+		&problem.BestScore,
 	)
+	if publishAt.Valid {
+		problem.PublishAt = &publishAt.Time
+	}
+	if rating.Valid {
+		problem.Rating = &rating.Float64
+		problem.RatingUpdatedAt = &ratingUpdatedAt.Time
+	}
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, problems.ErrProblemNotFound
 	}
@@ -125,7 +307,13 @@ func (sr *PGClient) GetProblemByUUID(uuid string, userID string) (*problems.Prob
 }
 
 func (sr *PGClient) GetTestCasesByProblemUUID(problemUUID string) ([]problems.TestCase, error) {
-	query := "SELECT id, input, output FROM testcases WHERE problem_uuid = $1"
+	query := `
+		SELECT id, COALESCE(input, ''), COALESCE(output, ''), COALESCE(input_key, ''), COALESCE(output_key, ''), 
+		       input_size, output_size, subtask_id 
+		FROM testcases 
+		WHERE problem_uuid = $1 
+		ORDER BY id
+	`
 	rows, err := sr.db.Query(query, problemUUID)
 	if err != nil {
 		return nil, err
@@ -134,8 +322,8 @@ func (sr *PGClient) GetTestCasesByProblemUUID(problemUUID string) ([]problems.Te
 
 	testCases := []problems.TestCase{}
 	for rows.Next() {
-		var testCase problems.TestCase
-		if err := rows.Scan(&testCase.ID, &testCase.Input, &testCase.Output); err != nil {
+		testCase, err := scanTestCase(rows)
+		if err != nil {
 			return nil, err
 		}
 		testCases = append(testCases, testCase)
@@ -148,24 +336,199 @@ func (sr *PGClient) GetTestCasesByProblemUUID(problemUUID string) ([]problems.Te
 	return testCases, nil
 }
 
-func (sr *PGClient) AddProblem(uuid, name, difficulty, description string) error {
+// AddProblem creates the problem together with its first revision
+func (sr *PGClient) AddProblem(uuid, authorUUID string, req problems.CreateProblemRequest) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO problems (uuid, name, difficulty, description) 
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO problems (uuid, name, difficulty, description, description_source, io_mode, input_file, output_file, current_revision, owner_uuid) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 1, $9)
 	`
-	_, err := sr.db.Exec(query, uuid, name, difficulty, description)
-	return err
+	_, err = tx.Exec(query,
+		uuid,
+		req.Name,
+		req.Difficulty,
+		req.DescriptionHTML,
+		req.Description,
+		req.Mode,
+		nullIfEmpty(req.InputFile),
+		nullIfEmpty(req.OutputFile),
+		nullIfEmpty(authorUUID),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := insertRevision(tx, uuid, 1, authorUUID, "", req); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTestCaseByID получает тест по идентификатору.
+func (sr *PGClient) GetTestCaseByID(id int) (problems.TestCase, error) {
+	query := `
+		SELECT id, COALESCE(input, ''), COALESCE(output, ''), COALESCE(input_key, ''), COALESCE(output_key, ''), 
+		       input_size, output_size, subtask_id 
+		FROM testcases 
+		WHERE id = $1
+	`
+	testCase, err := scanTestCase(sr.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return testCase, problems.ErrTestCaseNotFound
+	}
+	return testCase, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTestCase(row rowScanner) (problems.TestCase, error) {
+	var testCase problems.TestCase
+	var subtaskID sql.NullInt64
+	err := row.Scan(
+		&testCase.ID,
+		&testCase.Input,
+		&testCase.Output,
+		&testCase.InputKey,
+		&testCase.OutputKey,
+		&testCase.InputSize,
+		&testCase.OutputSize,
+		&subtaskID,
+	)
+	if subtaskID.Valid {
+		id := int(subtaskID.Int64)
+		testCase.SubtaskID = &id
+	}
+	return testCase, err
 }
 
-func (sr *PGClient) AddTestcase(problemUUID, input, output string) error {
+// AddTestcase records a test case; data referenced by keys is not stored inline.
+func (sr *PGClient) AddTestcase(problemUUID string, testCase problems.TestCase) error {
 	query := `
-		INSERT INTO testcases (problem_uuid, input, output) 
-		VALUES ($1, $2, $3)
+		INSERT INTO testcases (problem_uuid, input, output, input_key, output_key, input_size, output_size, subtask_id) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
-	_, err := sr.db.Exec(query, problemUUID, input, output)
+	_, err := sr.db.Exec(query,
+		problemUUID,
+		nullIfEmpty(testCase.Input),
+		nullIfEmpty(testCase.Output),
+		nullIfEmpty(testCase.InputKey),
+		nullIfEmpty(testCase.OutputKey),
+		testCase.InputSize,
+		testCase.OutputSize,
+		testCase.SubtaskID,
+	)
 	return err
 }
 
+// SaveTestcases appends test cases to the problem or replaces its test set in one transaction
+func (sr *PGClient) SaveTestcases(problemUUID string, testCases []problems.TestCase, replace bool) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.Exec("DELETE FROM testcases WHERE problem_uuid = $1", problemUUID); err != nil {
+			return err
+		}
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO testcases (problem_uuid, input, output, input_key, output_key, input_size, output_size, subtask_id) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, testCase := range testCases {
+		_, err := stmt.Exec(
+			problemUUID,
+			nullIfEmpty(testCase.Input),
+			nullIfEmpty(testCase.Output),
+			nullIfEmpty(testCase.InputKey),
+			nullIfEmpty(testCase.OutputKey),
+			testCase.InputSize,
+			testCase.OutputSize,
+			testCase.SubtaskID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// GetSubtasksByProblemUUID returns subtasks of the problem in their display order
+func (sr *PGClient) GetSubtasksByProblemUUID(problemUUID string) ([]problems.Subtask, error) {
+	query := `
+		SELECT id, problem_uuid, position, points, scoring, description 
+		FROM subtasks 
+		WHERE problem_uuid = $1 
+		ORDER BY position, id
+	`
+	rows, err := sr.db.Query(query, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subtasks := []problems.Subtask{}
+	for rows.Next() {
+		var st problems.Subtask
+		if err := rows.Scan(&st.ID, &st.ProblemUUID, &st.Position, &st.Points, &st.Scoring, &st.Description); err != nil {
+			return nil, err
+		}
+		subtasks = append(subtasks, st)
+	}
+	return subtasks, rows.Err()
+}
+
+func (sr *PGClient) AddSubtask(problemUUID string, req problems.CreateSubtaskRequest) (int, error) {
+	query := `
+		INSERT INTO subtasks (problem_uuid, position, points, scoring, description) 
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	var id int
+	err := sr.db.QueryRow(query, problemUUID, req.Position, req.Points, req.Scoring, req.Description).Scan(&id)
+	return id, err
+}
+
+func (sr *PGClient) DeleteSubtask(id int) error {
+	result, err := sr.db.Exec("DELETE FROM subtasks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrSubtaskNotFound
+	}
+
+	return nil
+}
+
+// GetAllProblems returns published problems with the progress of the user
 func (sr *PGClient) GetAllProblems(userID string) ([]problems.Problem, error) {
 	// Используем EXISTS подзапрос, чтобы проверить наличие хотя бы одного принятого решения
 	query := `
@@ -175,15 +538,40 @@ func (sr *PGClient) GetAllProblems(userID string) ([]problems.Problem, error) {
          p.name, 
          p.difficulty, 
          p.description,
+         p.io_mode,
+         COALESCE(p.input_file, ''),
+         COALESCE(p.output_file, ''),
+         p.current_revision,
+         p.status,
+         ARRAY(
+             SELECT t.name 
+             FROM problem_tags pt 
+             JOIN tags t ON t.id = pt.tag_id 
+             WHERE pt.problem_uuid = p.uuid 
+             ORDER BY t.name
+         ) AS tags,
          EXISTS (
              SELECT 1 
              FROM solutions s 
              WHERE s.problem_uuid = p.uuid 
                AND s.user_uuid = $1
                AND s.status = 'accepted'
-         ) AS solved
+         ) AS solved,
+         COALESCE((
+             SELECT SUM(st.points) 
+             FROM subtasks st 
+             WHERE st.problem_uuid = p.uuid
+         ), 100) AS max_score,
+         COALESCE((
+             SELECT MAX(s.score) 
+             FROM solutions s 
+             WHERE s.problem_uuid = p.uuid 
+               AND s.user_uuid = $1
+         ), 0) AS best_score
      FROM 
          problems p
+     WHERE 
+         p.status = 'published'
     `
 
 	rows, err := sr.db.Query(query, userID)
@@ -201,7 +589,15 @@ func (sr *PGClient) GetAllProblems(userID string) ([]problems.Problem, error) {
 			&problem.Name,
 			&problem.Difficulty,
 			&problem.Description,
+			&problem.Mode,
+			&problem.InputFile,
+			&problem.OutputFile,
+			&problem.Revision,
+			&problem.Status,
+			pq.Array(&problem.Tags),
 			&problem.Solved,
+			&problem.MaxScore,
+			&problem.BestScore,
 		); err != nil {
 			return nil, err
 		}
@@ -270,13 +666,24 @@ func (sr *PGClient) SaveSolution(userID, problemUUID string, solution problems.P
             code,
             language,
             created_at,
-            status
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            status,
+            score,
+            max_score,
+            subtask_scores,
+            problem_revision,
+            contest_id,
+            team_id
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
         RETURNING id
     `
 
+	subtaskScores, err := json.Marshal(solution.SubtaskScores)
+	if err != nil {
+		return 0, err
+	}
+
 	var solutionID int
-	err := sr.db.QueryRow(
+	err = sr.db.QueryRow(
 		query,
 		userID,
 		problemUUID,
@@ -286,6 +693,12 @@ func (sr *PGClient) SaveSolution(userID, problemUUID string, solution problems.P
 		solution.Language,
 		solution.CreatedAt,
 		status, // Добавляем параметр status в запрос
+		solution.Score,
+		solution.MaxScore,
+		subtaskScores,
+		solution.ProblemRevision,
+		solution.ContestID,
+		solution.TeamID,
 	).Scan(&solutionID)
 
 	return solutionID, err
@@ -299,6 +712,8 @@ func (sr *PGClient) GetSolutionByProblemAndUser(userID, problemUUID string) (pro
             memory_usage_kb,
             code,
 			language,
+            score,
+            max_score,
             created_at
         FROM 
             solutions
@@ -318,6 +733,8 @@ func (sr *PGClient) GetSolutionByProblemAndUser(userID, problemUUID string) (pro
 		&solution.AverageMemory,
 		&solution.Code,
 		&solution.Language,
+		&solution.Score,
+		&solution.MaxScore,
 		&createdAt,
 	)
 
@@ -463,6 +880,9 @@ func (sr *PGClient) GetUserSolutions(userID string, problemUUID string) ([]probl
             status,
             execution_time_ms,
             memory_usage_kb,
+            score,
+            max_score,
+            COALESCE(problem_revision, 0),
             created_at  -- Возвращаем нативный timestamp вместо форматированной строки
         FROM 
             solutions
@@ -489,6 +909,9 @@ func (sr *PGClient) GetUserSolutions(userID string, problemUUID string) ([]probl
 			&solution.Status,
 			&solution.AverageTime,
 			&solution.AverageMemory,
+			&solution.Score,
+			&solution.MaxScore,
+			&solution.ProblemRevision,
 			&solution.CreatedAt, // Теперь timestamp напрямую попадет в поле CreatedAt
 		); err != nil {
 			return nil, err
@@ -0,0 +1,153 @@
+package repo
+
+import (
+	"diplom/internal/plagiarism"
+	"encoding/json"
+)
+
+// GetProblemUUIDsWithAcceptedSolutions returns problems that have at least one accepted solution
+func (sr *PGClient) GetProblemUUIDsWithAcceptedSolutions() ([]string, error) {
+	rows, err := sr.db.Query("SELECT DISTINCT problem_uuid FROM solutions WHERE status = 'accepted'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problemUUIDs []string
+	for rows.Next() {
+		var problemUUID string
+		if err := rows.Scan(&problemUUID); err != nil {
+			return nil, err
+		}
+		problemUUIDs = append(problemUUIDs, problemUUID)
+	}
+	return problemUUIDs, rows.Err()
+}
+
+// GetLatestAcceptedSubmissions returns the latest accepted solution of every user per language
+func (sr *PGClient) GetLatestAcceptedSubmissions(problemUUID string) ([]plagiarism.Submission, error) {
+	query := `
+        SELECT DISTINCT ON (s.user_uuid, s.language)
+            s.id,
+            s.user_uuid,
+            u.username,
+            s.language,
+            s.code
+        FROM 
+            solutions s
+        JOIN users u ON u.uuid = s.user_uuid
+        WHERE 
+            s.problem_uuid = $1
+            AND s.status = 'accepted'
+        ORDER BY s.user_uuid, s.language, s.created_at DESC
+    `
+	rows, err := sr.db.Query(query, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var submissions []plagiarism.Submission
+	for rows.Next() {
+		var sub plagiarism.Submission
+		if err := rows.Scan(&sub.SolutionID, &sub.UserUUID, &sub.Username, &sub.Language, &sub.Code); err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, sub)
+	}
+	return submissions, rows.Err()
+}
+
+// ReplaceSimilarities atomically replaces all stored scores of the problem
+func (sr *PGClient) ReplaceSimilarities(problemUUID string, pairs []plagiarism.PairSimilarity) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM solution_similarities WHERE problem_uuid = $1", problemUUID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+        INSERT INTO solution_similarities (
+            problem_uuid, language, first_solution_id, second_solution_id, similarity, fragments, computed_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, pair := range pairs {
+		fragments, err := json.Marshal(pair.Fragments)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(
+			problemUUID,
+			pair.Language,
+			pair.First.SolutionID,
+			pair.Second.SolutionID,
+			pair.Similarity,
+			fragments,
+			pair.ComputedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSimilarities returns stored pairs of the problem with a score of at least minSimilarity
+func (sr *PGClient) GetSimilarities(problemUUID string, minSimilarity float64) ([]plagiarism.PairSimilarity, error) {
+	query := `
+        SELECT 
+            ss.problem_uuid,
+            ss.language,
+            ss.similarity,
+            ss.fragments,
+            ss.computed_at,
+            s1.id, s1.user_uuid, u1.username, s1.language,
+            s2.id, s2.user_uuid, u2.username, s2.language
+        FROM 
+            solution_similarities ss
+        JOIN solutions s1 ON s1.id = ss.first_solution_id
+        JOIN users u1 ON u1.uuid = s1.user_uuid
+        JOIN solutions s2 ON s2.id = ss.second_solution_id
+        JOIN users u2 ON u2.uuid = s2.user_uuid
+        WHERE 
+            ss.problem_uuid = $1
+            AND ss.similarity >= $2
+        ORDER BY ss.similarity DESC
+    `
+	rows, err := sr.db.Query(query, problemUUID, minSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pairs := []plagiarism.PairSimilarity{}
+	for rows.Next() {
+		var pair plagiarism.PairSimilarity
+		var fragments []byte
+		if err := rows.Scan(
+			&pair.ProblemUUID,
+			&pair.Language,
+			&pair.Similarity,
+			&fragments,
+			&pair.ComputedAt,
+			&pair.First.SolutionID, &pair.First.UserUUID, &pair.First.Username, &pair.First.Language,
+			&pair.Second.SolutionID, &pair.Second.UserUUID, &pair.Second.Username, &pair.Second.Language,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fragments, &pair.Fragments); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, rows.Err()
+}
@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/problems"
+	"errors"
+)
+
+func insertRevision(tx *sql.Tx, problemUUID string, revision int, authorUUID, comment string, req problems.CreateProblemRequest) error {
+	query := `
+		INSERT INTO problem_revisions (
+			problem_uuid, revision, name, difficulty, description, io_mode, input_file, output_file, author_uuid, comment
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := tx.Exec(query,
+		problemUUID,
+		revision,
+		req.Name,
+		req.Difficulty,
+		req.Description,
+		req.Mode,
+		nullIfEmpty(req.InputFile),
+		nullIfEmpty(req.OutputFile),
+		nullIfEmpty(authorUUID),
+		comment,
+	)
+	return err
+}
+
+// UpdateProblem records a new revision and makes it the current state of the problem
+func (sr *PGClient) UpdateProblem(uuid, authorUUID string, req problems.UpdateProblemRequest) (int, error) {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the problem row so concurrent edits get consecutive revisions
+	var revision int
+	err = tx.QueryRow("SELECT current_revision FROM problems WHERE uuid = $1 FOR UPDATE", uuid).Scan(&revision)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, problems.ErrProblemNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	revision++
+
+	if err := insertRevision(tx, uuid, revision, authorUUID, req.Comment, req.CreateProblemRequest); err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE problems 
+		SET name = $2, difficulty = $3, description = $4, description_source = $5, 
+		    io_mode = $6, input_file = $7, output_file = $8, current_revision = $9
+		WHERE uuid = $1
+	`
+	_, err = tx.Exec(query,
+		uuid,
+		req.Name,
+		req.Difficulty,
+		req.DescriptionHTML,
+		req.Description,
+		req.Mode,
+		nullIfEmpty(req.InputFile),
+		nullIfEmpty(req.OutputFile),
+		revision,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return revision, tx.Commit()
+}
+
+const revisionColumns = `
+	problem_uuid, revision, name, difficulty, COALESCE(description, ''), io_mode, 
+	COALESCE(input_file, ''), COALESCE(output_file, ''), COALESCE(author_uuid, ''), comment, created_at
+`
+
+func scanRevision(row rowScanner) (problems.ProblemRevision, error) {
+	var rev problems.ProblemRevision
+	err := row.Scan(
+		&rev.ProblemUUID,
+		&rev.Revision,
+		&rev.Name,
+		&rev.Difficulty,
+		&rev.Description,
+		&rev.Mode,
+		&rev.InputFile,
+		&rev.OutputFile,
+		&rev.AuthorUUID,
+		&rev.Comment,
+		&rev.CreatedAt,
+	)
+	return rev, err
+}
+
+// GetProblemRevisions returns all revisions of the problem, newest first
+func (sr *PGClient) GetProblemRevisions(uuid string) ([]problems.ProblemRevision, error) {
+	query := "SELECT " + revisionColumns + " FROM problem_revisions WHERE problem_uuid = $1 ORDER BY revision DESC"
+	rows, err := sr.db.Query(query, uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []problems.ProblemRevision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// GetProblemRevision returns a single revision of the problem
+func (sr *PGClient) GetProblemRevision(uuid string, revision int) (problems.ProblemRevision, error) {
+	query := "SELECT " + revisionColumns + " FROM problem_revisions WHERE problem_uuid = $1 AND revision = $2"
+	rev, err := scanRevision(sr.db.QueryRow(query, uuid, revision))
+	if errors.Is(err, sql.ErrNoRows) {
+		return rev, problems.ErrRevisionNotFound
+	}
+	return rev, err
+}
@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"diplom/internal/problems"
+	"time"
+)
+
+// GetStarterCode returns the custom templates of the problem keyed by language
+func (sr *PGClient) GetStarterCode(problemUUID string) (map[string]string, error) {
+	rows, err := sr.db.Query("SELECT language, code FROM starter_code WHERE problem_uuid = $1", problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	starter := make(map[string]string)
+	for rows.Next() {
+		var language, code string
+		if err := rows.Scan(&language, &code); err != nil {
+			return nil, err
+		}
+		starter[language] = code
+	}
+	return starter, rows.Err()
+}
+
+func (sr *PGClient) SetStarterCode(problemUUID, language, code string) error {
+	query := `
+		INSERT INTO starter_code (problem_uuid, language, code) 
+		VALUES ($1, $2, $3)
+		ON CONFLICT (problem_uuid, language) DO UPDATE SET code = EXCLUDED.code
+	`
+	_, err := sr.db.Exec(query, problemUUID, language, code)
+	return err
+}
+
+func (sr *PGClient) DeleteStarterCode(problemUUID, language string) error {
+	result, err := sr.db.Exec("DELETE FROM starter_code WHERE problem_uuid = $1 AND language = $2", problemUUID, language)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrStarterCodeNotFound
+	}
+
+	return nil
+}
+
+// GetDrafts returns the user's drafts for the problem, the most recent first
+func (sr *PGClient) GetDrafts(userID, problemUUID string) ([]problems.Draft, error) {
+	query := `
+		SELECT language, code, updated_at 
+		FROM code_drafts 
+		WHERE user_uuid = $1 AND problem_uuid = $2 
+		ORDER BY updated_at DESC
+	`
+	rows, err := sr.db.Query(query, userID, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []problems.Draft{}
+	for rows.Next() {
+		var d problems.Draft
+		if err := rows.Scan(&d.Language, &d.Code, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	return drafts, rows.Err()
+}
+
+// SaveDraft creates or overwrites the draft and returns the time it was saved
+func (sr *PGClient) SaveDraft(userID, problemUUID, language, code string) (time.Time, error) {
+	query := `
+		INSERT INTO code_drafts (user_uuid, problem_uuid, language, code) 
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_uuid, problem_uuid, language) DO UPDATE 
+		SET code = EXCLUDED.code, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+	var updatedAt time.Time
+	err := sr.db.QueryRow(query, userID, problemUUID, language, code).Scan(&updatedAt)
+	return updatedAt, err
+}
+
+func (sr *PGClient) DeleteDraft(userID, problemUUID, language string) error {
+	result, err := sr.db.Exec(
+		"DELETE FROM code_drafts WHERE user_uuid = $1 AND problem_uuid = $2 AND language = $3",
+		userID, problemUUID, language,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrDraftNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package repo
+
+import (
+	"diplom/internal/problems"
+	"math"
+)
+
+// GetProblemStats aggregates verdicts, languages and execution times of all submissions to the problem
+func (sr *PGClient) GetProblemStats(problemUUID string) (problems.ProblemStats, error) {
+	stats := problems.ProblemStats{
+		Verdicts:  map[string]int{},
+		Languages: []problems.LanguageStats{},
+	}
+
+	query := `
+		SELECT 
+			COUNT(*),
+			COUNT(DISTINCT user_uuid),
+			COUNT(DISTINCT user_uuid) FILTER (WHERE status = 'accepted'),
+			COUNT(*) FILTER (WHERE status = 'accepted'),
+			COUNT(*) FILTER (WHERE status = 'rejected' AND score > 0),
+			COUNT(*) FILTER (WHERE status = 'rejected' AND score = 0)
+		FROM solutions 
+		WHERE problem_uuid = $1
+	`
+	var accepted, partial, rejected int
+	err := sr.db.QueryRow(query, problemUUID).Scan(
+		&stats.Attempts,
+		&stats.UniqueAttempters,
+		&stats.UniqueSolvers,
+		&accepted,
+		&partial,
+		&rejected,
+	)
+	if err != nil {
+		return stats, err
+	}
+	stats.Verdicts[problems.VerdictAccepted] = accepted
+	stats.Verdicts[problems.VerdictPartial] = partial
+	stats.Verdicts[problems.VerdictRejected] = rejected
+
+	rows, err := sr.db.Query(`
+		SELECT language, COUNT(*), COUNT(*) FILTER (WHERE status = 'accepted') 
+		FROM solutions 
+		WHERE problem_uuid = $1 
+		GROUP BY language 
+		ORDER BY COUNT(*) DESC, language
+	`, problemUUID)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	index := map[string]int{}
+	for rows.Next() {
+		var ls problems.LanguageStats
+		if err := rows.Scan(&ls.Language, &ls.Attempts, &ls.Accepted); err != nil {
+			return stats, err
+		}
+		ls.TimeHistogram = []problems.HistogramBucket{}
+		index[ls.Language] = len(stats.Languages)
+		stats.Languages = append(stats.Languages, ls)
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	// Равные интервалы между минимальным и максимальным временем принятых решений языка
+	histRows, err := sr.db.Query(`
+		WITH accepted AS (
+			SELECT language, execution_time_ms AS t 
+			FROM solutions 
+			WHERE problem_uuid = $1 AND status = 'accepted'
+		), bounds AS (
+			SELECT language, MIN(t) AS lo, MAX(t) AS hi 
+			FROM accepted 
+			GROUP BY language
+		)
+		SELECT a.language, b.lo, b.hi, 
+		       CASE WHEN b.hi = b.lo THEN 0 
+		            ELSE LEAST(FLOOR((a.t - b.lo) / (b.hi - b.lo) * $2)::int, $2 - 1) 
+		       END AS bucket,
+		       COUNT(*)
+		FROM accepted a 
+		JOIN bounds b ON b.language = a.language 
+		GROUP BY a.language, b.lo, b.hi, bucket 
+		ORDER BY a.language, bucket
+	`, problemUUID, problems.HistogramBuckets)
+	if err != nil {
+		return stats, err
+	}
+	defer histRows.Close()
+
+	for histRows.Next() {
+		var language string
+		var lo, hi float64
+		var bucket, count int
+		if err := histRows.Scan(&language, &lo, &hi, &bucket, &count); err != nil {
+			return stats, err
+		}
+		i, ok := index[language]
+		if !ok {
+			continue
+		}
+
+		ls := &stats.Languages[i]
+		if len(ls.TimeHistogram) == 0 {
+			ls.TimeHistogram = newHistogram(lo, hi)
+		}
+		ls.TimeHistogram[min(bucket, len(ls.TimeHistogram)-1)].Count += count
+	}
+	return stats, histRows.Err()
+}
+
+// newHistogram splits [lo, hi] into equal buckets, a single bucket when all times are equal
+func newHistogram(lo, hi float64) []problems.HistogramBucket {
+	if hi <= lo {
+		return []problems.HistogramBucket{{FromMS: lo, ToMS: hi}}
+	}
+
+	width := (hi - lo) / problems.HistogramBuckets
+	buckets := make([]problems.HistogramBucket, problems.HistogramBuckets)
+	for i := range buckets {
+		buckets[i].FromMS = math.Round((lo+width*float64(i))*100) / 100
+		buckets[i].ToMS = math.Round((lo+width*float64(i+1))*100) / 100
+	}
+	return buckets
+}
+
+// GetRatingInputs collects solver data of every problem for difficulty ratings
+func (sr *PGClient) GetRatingInputs() ([]problems.RatingInput, error) {
+	query := `
+		WITH marked AS (
+			SELECT problem_uuid, user_uuid, created_at, 
+			       MIN(created_at) FILTER (WHERE status = 'accepted') 
+			           OVER (PARTITION BY problem_uuid, user_uuid) AS first_accepted
+			FROM solutions
+		), per_user AS (
+			SELECT problem_uuid, user_uuid, 
+			       MAX(first_accepted) AS first_accepted,
+			       COUNT(*) FILTER (WHERE created_at <= first_accepted) AS attempts_to_solve
+			FROM marked 
+			GROUP BY problem_uuid, user_uuid
+		)
+		SELECT p.uuid, p.difficulty, 
+		       COUNT(pu.user_uuid), 
+		       COUNT(pu.first_accepted), 
+		       COALESCE(AVG(pu.attempts_to_solve) FILTER (WHERE pu.first_accepted IS NOT NULL), 0)::float
+		FROM problems p 
+		LEFT JOIN per_user pu ON pu.problem_uuid = p.uuid 
+		GROUP BY p.uuid, p.difficulty
+	`
+	rows, err := sr.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var inputs []problems.RatingInput
+	for rows.Next() {
+		var in problems.RatingInput
+		if err := rows.Scan(&in.ProblemUUID, &in.Difficulty, &in.Attempters, &in.Solvers, &in.AvgAttemptsToSolve); err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, in)
+	}
+	return inputs, rows.Err()
+}
+
+// SetProblemRatings stores recomputed ratings, nil clears the rating of a problem
+func (sr *PGClient) SetProblemRatings(ratings map[string]*float64) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE problems SET rating = $2, rating_updated_at = NOW() WHERE uuid = $1")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for problemUUID, rating := range ratings {
+		if _, err := stmt.Exec(problemUUID, rating); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,267 @@
+package repo
+
+import (
+	"database/sql"
+	"diplom/internal/teams"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// CreateTeam creates a team and adds the captain as its first member
+func (sr *PGClient) CreateTeam(name, captainUUID string) (int, error) {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(
+		"INSERT INTO teams (name, captain_uuid) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING RETURNING id",
+		name, captainUUID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, teams.ErrTeamNameTaken
+	} else if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("INSERT INTO team_members (team_id, user_uuid) VALUES ($1, $2)", id, captainUUID); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (sr *PGClient) GetTeam(id int) (teams.Team, error) {
+	var t teams.Team
+	err := sr.db.QueryRow("SELECT id, name, captain_uuid, created_at FROM teams WHERE id = $1", id).
+		Scan(&t.ID, &t.Name, &t.CaptainUUID, &t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return teams.Team{}, teams.ErrTeamNotFound
+	} else if err != nil {
+		return teams.Team{}, err
+	}
+
+	members, err := sr.getTeamMembers([]int{id})
+	if err != nil {
+		return teams.Team{}, err
+	}
+	t.Members = members[id]
+	return t, nil
+}
+
+// GetUserTeams returns the teams the user is a member of
+func (sr *PGClient) GetUserTeams(userID string) ([]teams.Team, error) {
+	query := `
+		SELECT t.id, t.name, t.captain_uuid, t.created_at
+		FROM teams t
+		JOIN team_members tm ON tm.team_id = t.id
+		WHERE tm.user_uuid = $1
+		ORDER BY t.name
+	`
+	rows, err := sr.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []teams.Team{}
+	var ids []int
+	for rows.Next() {
+		var t teams.Team
+		if err := rows.Scan(&t.ID, &t.Name, &t.CaptainUUID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, t)
+		ids = append(ids, t.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	members, err := sr.getTeamMembers(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].Members = members[result[i].ID]
+	}
+	return result, nil
+}
+
+func (sr *PGClient) getTeamMembers(teamIDs []int) (map[int][]teams.Member, error) {
+	query := `
+		SELECT tm.team_id, tm.user_uuid, u.username, tm.joined_at
+		FROM team_members tm
+		JOIN users u ON u.uuid = tm.user_uuid
+		WHERE tm.team_id = ANY($1)
+		ORDER BY tm.joined_at, u.username
+	`
+	rows, err := sr.db.Query(query, pq.Array(teamIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make(map[int][]teams.Member, len(teamIDs))
+	for _, id := range teamIDs {
+		members[id] = []teams.Member{}
+	}
+	for rows.Next() {
+		var teamID int
+		var m teams.Member
+		if err := rows.Scan(&teamID, &m.UserUUID, &m.Username, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members[teamID] = append(members[teamID], m)
+	}
+	return members, rows.Err()
+}
+
+func (sr *PGClient) DeleteTeam(id int) error {
+	result, err := sr.db.Exec("DELETE FROM teams WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return teams.ErrTeamNotFound
+	}
+
+	return nil
+}
+
+func (sr *PGClient) GetUserUUIDByUsername(username string) (string, error) {
+	var userUUID string
+	err := sr.db.QueryRow("SELECT uuid FROM users WHERE username = $1", username).Scan(&userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", teams.ErrUserNotFound
+	}
+	return userUUID, err
+}
+
+func (sr *PGClient) CreateInvitation(teamID int, userUUID, invitedBy string) (int, error) {
+	var id int
+	err := sr.db.QueryRow(`
+		INSERT INTO team_invitations (team_id, user_uuid, invited_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (team_id, user_uuid) DO NOTHING
+		RETURNING id
+	`, teamID, userUUID, invitedBy).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, teams.ErrAlreadyInvited
+	}
+	return id, err
+}
+
+const invitationSelect = `
+	SELECT ti.id, ti.team_id, t.name, ti.user_uuid, u.username, ti.created_at
+	FROM team_invitations ti
+	JOIN teams t ON t.id = ti.team_id
+	JOIN users u ON u.uuid = ti.user_uuid`
+
+func (sr *PGClient) queryInvitations(query string, args ...any) ([]teams.Invitation, error) {
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []teams.Invitation{}
+	for rows.Next() {
+		var inv teams.Invitation
+		if err := rows.Scan(&inv.ID, &inv.TeamID, &inv.TeamName, &inv.UserUUID, &inv.Username, &inv.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, inv)
+	}
+	return result, rows.Err()
+}
+
+func (sr *PGClient) GetInvitation(id int) (teams.Invitation, error) {
+	invitations, err := sr.queryInvitations(invitationSelect+" WHERE ti.id = $1", id)
+	if err != nil {
+		return teams.Invitation{}, err
+	}
+	if len(invitations) == 0 {
+		return teams.Invitation{}, teams.ErrInvitationNotFound
+	}
+	return invitations[0], nil
+}
+
+func (sr *PGClient) GetUserInvitations(userID string) ([]teams.Invitation, error) {
+	return sr.queryInvitations(invitationSelect+" WHERE ti.user_uuid = $1 ORDER BY ti.created_at DESC", userID)
+}
+
+func (sr *PGClient) GetTeamInvitations(teamID int) ([]teams.Invitation, error) {
+	return sr.queryInvitations(invitationSelect+" WHERE ti.team_id = $1 ORDER BY ti.created_at", teamID)
+}
+
+// AcceptInvitation adds the invited user to the team and removes the invitation
+func (sr *PGClient) AcceptInvitation(id int) error {
+	tx, err := sr.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var teamID int
+	var userUUID string
+	err = tx.QueryRow("DELETE FROM team_invitations WHERE id = $1 RETURNING team_id, user_uuid", id).Scan(&teamID, &userUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return teams.ErrInvitationNotFound
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO team_members (team_id, user_uuid) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		teamID, userUUID,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (sr *PGClient) DeleteInvitation(id int) error {
+	result, err := sr.db.Exec("DELETE FROM team_invitations WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return teams.ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+func (sr *PGClient) RemoveTeamMember(teamID int, userUUID string) error {
+	result, err := sr.db.Exec("DELETE FROM team_members WHERE team_id = $1 AND user_uuid = $2", teamID, userUUID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return teams.ErrMemberNotFound
+	}
+
+	return nil
+}
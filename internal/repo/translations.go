@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"diplom/internal/problems"
+
+	"github.com/lib/pq"
+)
+
+// GetProblemTranslations returns all translations of the problem ordered by locale
+func (sr *PGClient) GetProblemTranslations(problemUUID string) ([]problems.Translation, error) {
+	query := `
+		SELECT locale, name, description, description_source, input_format, input_format_source, 
+		       output_format, output_format_source, updated_at 
+		FROM problem_translations 
+		WHERE problem_uuid = $1 
+		ORDER BY locale
+	`
+	rows, err := sr.db.Query(query, problemUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []problems.Translation{}
+	for rows.Next() {
+		var t problems.Translation
+		err := rows.Scan(
+			&t.Locale,
+			&t.Name,
+			&t.Description,
+			&t.DescriptionSource,
+			&t.InputFormat,
+			&t.InputFormatSource,
+			&t.OutputFormat,
+			&t.OutputFormatSource,
+			&t.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, t)
+	}
+	return translations, rows.Err()
+}
+
+// GetTranslatedNames returns translated names keyed by problem UUID and locale
+func (sr *PGClient) GetTranslatedNames(problemUUIDs []string) (map[string]map[string]string, error) {
+	rows, err := sr.db.Query(
+		"SELECT problem_uuid, locale, name FROM problem_translations WHERE problem_uuid = ANY($1)",
+		pq.Array(problemUUIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]map[string]string)
+	for rows.Next() {
+		var problemUUID, locale, name string
+		if err := rows.Scan(&problemUUID, &locale, &name); err != nil {
+			return nil, err
+		}
+		if names[problemUUID] == nil {
+			names[problemUUID] = make(map[string]string)
+		}
+		names[problemUUID][locale] = name
+	}
+	return names, rows.Err()
+}
+
+// SetProblemTranslation creates or replaces the translation in t.Locale
+func (sr *PGClient) SetProblemTranslation(problemUUID string, t problems.Translation) error {
+	query := `
+		INSERT INTO problem_translations (problem_uuid, locale, name, description, description_source, 
+		                                  input_format, input_format_source, output_format, output_format_source) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (problem_uuid, locale) DO UPDATE 
+		SET name = EXCLUDED.name, 
+		    description = EXCLUDED.description, 
+		    description_source = EXCLUDED.description_source, 
+		    input_format = EXCLUDED.input_format, 
+		    input_format_source = EXCLUDED.input_format_source, 
+		    output_format = EXCLUDED.output_format, 
+		    output_format_source = EXCLUDED.output_format_source, 
+		    updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := sr.db.Exec(query,
+		problemUUID,
+		t.Locale,
+		t.Name,
+		t.Description,
+		t.DescriptionSource,
+		t.InputFormat,
+		t.InputFormatSource,
+		t.OutputFormat,
+		t.OutputFormatSource,
+	)
+	return err
+}
+
+func (sr *PGClient) DeleteProblemTranslation(problemUUID, locale string) error {
+	result, err := sr.db.Exec("DELETE FROM problem_translations WHERE problem_uuid = $1 AND locale = $2", problemUUID, locale)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return problems.ErrTranslationNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore keeps objects in a directory on the local filesystem,
+// sharded by the first two bytes of the key
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates the store directory if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+func (s *LocalStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key[2:4], key)
+}
+
+func (s *LocalStore) Put(_ context.Context, r io.Reader) (string, int64, error) {
+	tmp, key, size, err := spool(s.dir, r)
+	if err != nil {
+		return "", 0, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	target := s.path(key)
+	if _, err := os.Stat(target); err == nil {
+		return key, size, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+func (s *LocalStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Exists(_ context.Context, key string) (bool, error) {
+	if err := ValidateKey(key); err != nil {
+		return false, err
+	}
+	_, err := os.Stat(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
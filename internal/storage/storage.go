@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"diplom/config"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+)
+
+// Backend names accepted in the configuration
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+var (
+	ErrObjectNotFound  = errors.New("object not found")
+	ErrChecksumInvalid = errors.New("object checksum mismatch")
+	ErrInvalidKey      = errors.New("invalid object key")
+)
+
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Store is a content-addressed blob store: objects are identified by the
+// hex-encoded SHA-256 of their content
+type Store interface {
+	// Put stores the content and returns its key and size
+	Put(ctx context.Context, r io.Reader) (key string, size int64, err error)
+	// Get opens the object for reading
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Exists reports whether the object is stored
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the object, missing objects are not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStoreFromConfig creates the store selected in the configuration
+func NewStoreFromConfig(cfg config.StorageConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return NewLocalStore(cfg.LocalDir)
+	case BackendS3:
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s", cfg.Backend)
+	}
+}
+
+// ValidateKey checks that the key looks like a SHA-256 digest
+func ValidateKey(key string) error {
+	if !keyPattern.MatchString(key) {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// spool copies the content into a temporary file while hashing it, so the
+// key is known before the object is written to its final location
+func spool(dir string, r io.Reader) (*os.File, string, int64, error) {
+	tmp, err := os.CreateTemp(dir, "upload-*")
+	if err != nil {
+		return nil, "", 0, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, "", 0, err
+	}
+	return tmp, hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// VerifyFile checks that the file content matches its key
+func VerifyFile(path, key string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != key {
+		return ErrChecksumInvalid
+	}
+	return nil
+}
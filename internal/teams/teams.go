@@ -0,0 +1,238 @@
+package teams
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// MaxTeamSize is the number of members of an ICPC team
+const MaxTeamSize = 3
+
+// maxTeamNameLength limits team names (in characters)
+const maxTeamNameLength = 64
+
+var (
+	ErrTeamNotFound       = errors.New("team not found")
+	ErrInvalidTeamName    = errors.New("team name must be 1 to 64 characters long")
+	ErrTeamNameTaken      = errors.New("team name is already taken")
+	ErrNotCaptain         = errors.New("only the team captain may do this")
+	ErrTeamFull           = errors.New("team already has the maximum number of members")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrAlreadyMember      = errors.New("user is already a member of the team")
+	ErrAlreadyInvited     = errors.New("user is already invited to the team")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrMemberNotFound     = errors.New("user is not a member of the team")
+	ErrCaptainCannotLeave = errors.New("captain cannot leave the team, delete it instead")
+)
+
+// Team is a group of users taking part in team contests together.
+// Invitations are only listed for the captain.
+type Team struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	CaptainUUID string       `json:"captain_uuid"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Members     []Member     `json:"members"`
+	Invitations []Invitation `json:"invitations,omitempty"`
+}
+
+// Member is a user of a team
+type Member struct {
+	UserUUID string    `json:"user_uuid"`
+	Username string    `json:"username"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Invitation is a pending request for a user to join a team
+type Invitation struct {
+	ID        int       `json:"id"`
+	TeamID    int       `json:"team_id"`
+	TeamName  string    `json:"team_name"`
+	UserUUID  string    `json:"user_uuid"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateTeamRequest creates a team with the caller as its captain
+type CreateTeamRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// InviteRequest invites a user to the team by username
+type InviteRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// Repository defines the data access interface for teams
+type Repository interface {
+	CreateTeam(name, captainUUID string) (int, error)
+	GetTeam(id int) (Team, error)
+	GetUserTeams(userID string) ([]Team, error)
+	DeleteTeam(id int) error
+	GetUserUUIDByUsername(username string) (string, error)
+	CreateInvitation(teamID int, userUUID, invitedBy string) (int, error)
+	GetInvitation(id int) (Invitation, error)
+	GetUserInvitations(userID string) ([]Invitation, error)
+	GetTeamInvitations(teamID int) ([]Invitation, error)
+	AcceptInvitation(id int) error
+	DeleteInvitation(id int) error
+	RemoveTeamMember(teamID int, userUUID string) error
+}
+
+// Service manages teams, their members and invitations
+type Service struct {
+	Repo   Repository
+	Logger *zap.Logger
+}
+
+// NewService creates a team service
+func NewService(repo Repository, logger *zap.Logger) *Service {
+	return &Service{
+		Repo:   repo,
+		Logger: logger.Named("teams"),
+	}
+}
+
+// IsMember reports whether the user belongs to the team
+func (t *Team) IsMember(userID string) bool {
+	for _, m := range t.Members {
+		if m.UserUUID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateTeam creates a team with the user as captain and its first member
+func (s *Service) CreateTeam(userID string, req CreateTeamRequest) (Team, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxTeamNameLength {
+		return Team{}, ErrInvalidTeamName
+	}
+	id, err := s.Repo.CreateTeam(name, userID)
+	if err != nil {
+		return Team{}, err
+	}
+	return s.Repo.GetTeam(id)
+}
+
+// GetTeam returns a team; its pending invitations are shown to the captain
+func (s *Service) GetTeam(id int, userID string) (Team, error) {
+	team, err := s.Repo.GetTeam(id)
+	if err != nil {
+		return Team{}, err
+	}
+	if team.CaptainUUID == userID {
+		team.Invitations, err = s.Repo.GetTeamInvitations(id)
+		if err != nil {
+			return Team{}, err
+		}
+	}
+	return team, nil
+}
+
+// GetUserTeams returns the teams of the user and invitations waiting for an answer
+func (s *Service) GetUserTeams(userID string) ([]Team, []Invitation, error) {
+	userTeams, err := s.Repo.GetUserTeams(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	invitations, err := s.Repo.GetUserInvitations(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return userTeams, invitations, nil
+}
+
+// getCaptainTeam returns the team if the user is its captain
+func (s *Service) getCaptainTeam(id int, userID string) (Team, error) {
+	team, err := s.Repo.GetTeam(id)
+	if err != nil {
+		return Team{}, err
+	}
+	if team.CaptainUUID != userID {
+		return Team{}, ErrNotCaptain
+	}
+	return team, nil
+}
+
+// Invite invites a user to the team on behalf of the captain
+func (s *Service) Invite(id int, captainUUID string, req InviteRequest) (Invitation, error) {
+	team, err := s.getCaptainTeam(id, captainUUID)
+	if err != nil {
+		return Invitation{}, err
+	}
+	if len(team.Members) >= MaxTeamSize {
+		return Invitation{}, ErrTeamFull
+	}
+
+	userUUID, err := s.Repo.GetUserUUIDByUsername(req.Username)
+	if err != nil {
+		return Invitation{}, err
+	}
+	if team.IsMember(userUUID) {
+		return Invitation{}, ErrAlreadyMember
+	}
+
+	invitationID, err := s.Repo.CreateInvitation(id, userUUID, captainUUID)
+	if err != nil {
+		return Invitation{}, err
+	}
+	return s.Repo.GetInvitation(invitationID)
+}
+
+// RespondInvitation accepts or declines an invitation of the user.
+// The team captain may also withdraw a pending invitation by declining it.
+func (s *Service) RespondInvitation(invitationID int, userID string, accept bool) error {
+	invitation, err := s.Repo.GetInvitation(invitationID)
+	if err != nil {
+		return err
+	}
+	team, err := s.Repo.GetTeam(invitation.TeamID)
+	if err != nil {
+		return err
+	}
+	withdraw := !accept && team.CaptainUUID == userID
+	if invitation.UserUUID != userID && !withdraw {
+		return ErrInvitationNotFound
+	}
+
+	if !accept {
+		return s.Repo.DeleteInvitation(invitationID)
+	}
+	if len(team.Members) >= MaxTeamSize {
+		return ErrTeamFull
+	}
+	return s.Repo.AcceptInvitation(invitationID)
+}
+
+// RemoveMember removes a member from the team. The captain may remove anyone
+// else, other members may only leave themselves.
+func (s *Service) RemoveMember(id int, userID, memberUUID string) error {
+	team, err := s.Repo.GetTeam(id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case memberUUID == team.CaptainUUID:
+		return ErrCaptainCannotLeave
+	case userID != team.CaptainUUID && userID != memberUUID:
+		return ErrNotCaptain
+	case !team.IsMember(memberUUID):
+		return ErrMemberNotFound
+	}
+	return s.Repo.RemoveTeamMember(id, memberUUID)
+}
+
+// DeleteTeam deletes the team on behalf of the captain. The team disappears
+// from contest standings, submissions of its members are kept.
+func (s *Service) DeleteTeam(id int, userID string) error {
+	if _, err := s.getCaptainTeam(id, userID); err != nil {
+		return err
+	}
+	return s.Repo.DeleteTeam(id)
+}
@@ -2,10 +2,27 @@ package user
 
 import (
 	"diplom/internal/problems"
+	"errors"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
+// User is an account as seen by administrators
+type User struct {
+	UUID      string    `json:"uuid"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetRoleRequest changes the role of a user
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
 type UserService struct {
 	UserRepo UserRepository
 	Logger   *zap.Logger
@@ -22,4 +39,6 @@ type UserRepository interface {
 	GetUserStreaks(userID string) (currentStreak int, longestStreak int, err error)
 	CalculateSuccessRate(userID string) (float64, error)
 	GetUserSolutions(userID string, problemUUID string) ([]problems.ProblemSolution, error)
+	ListUsers() ([]User, error)
+	SetUserRole(userUUID, role string) error
 }
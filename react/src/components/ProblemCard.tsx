@@ -99,27 +99,48 @@ export default function ProblemCard({ problem, index, isAlternate = false }: Pro
             {/* Enhanced difficulty badge with gradient */}
             <div className={`inline-flex items-center px-4 py-1.5 rounded-full text-sm font-semibold shadow-md transition-transform duration-300 hover:scale-105 bg-gradient-to-r ${difficultyStyles} text-white`}>
               {difficultyNames[problem.difficulty]}
+              {problem.rating !== undefined && (
+                <span className="ml-2 opacity-80" title="Рейтинг сложности по решениям">{problem.rating}</span>
+              )}
             </div>
           </div>
           
           <div className="flex items-start bg-slate-50 rounded-lg p-3">
             <div className="card-description-container flex-1 min-w-0 mr-3">
-              {/* Render HTML content safely with fallback */}
-              <div 
-                ref={descriptionRef}
-                className="text-sm text-gray-700 line-clamp-2 prose prose-sm max-w-full break-words overflow-hidden"
-                dangerouslySetInnerHTML={{ __html: problem.description }}
-              ></div>
-              
-              {/* Fallback for complex HTML that might not display well */}
-              <div className="text-sm text-gray-700 line-clamp-2 hidden">
-                {getPlainTextFromHtml(problem.description)}
-              </div>
+              {/* The paginated list returns summaries: show tags instead of the statement */}
+              {problem.description === undefined ? (
+                <div className="flex flex-wrap gap-1.5">
+                  {(problem.tags || []).map(tag => (
+                    <span key={tag} className="px-2 py-0.5 rounded-full bg-white text-xs text-gray-600 border">{tag}</span>
+                  ))}
+                  {problem.acceptance_rate !== undefined && (
+                    <span className="text-xs text-gray-500">Принято: {problem.acceptance_rate}%</span>
+                  )}
+                </div>
+              ) : (
+                <>
+                  {/* Render HTML content safely with fallback */}
+                  <div 
+                    ref={descriptionRef}
+                    className="text-sm text-gray-700 line-clamp-2 prose prose-sm max-w-full break-words overflow-hidden"
+                    dangerouslySetInnerHTML={{ __html: problem.description }}
+                  ></div>
+                  
+                  {/* Fallback for complex HTML that might not display well */}
+                  <div className="text-sm text-gray-700 line-clamp-2 hidden">
+                    {getPlainTextFromHtml(problem.description)}
+                  </div>
+                </>
+              )}
             </div>
-            {problem.solved && (
+            {problem.solved ? (
               <div className="flex-shrink-0 bg-green-100 p-1.5 rounded-full">
                 <CheckCircle2 className="h-5 w-5 text-green-600" />
               </div>
+            ) : !!problem.best_score && (
+              <div className="flex-shrink-0 bg-amber-100 px-2 py-1 rounded-full text-xs font-semibold text-amber-700">
+                {problem.best_score}/{problem.max_score}
+              </div>
             )}
           </div>
         </CardContent>
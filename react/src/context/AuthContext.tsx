@@ -1,12 +1,14 @@
 // src/context/AuthContext.tsx
 import React, { createContext, useContext, useState, useEffect } from 'react'
 import { useNavigate } from 'react-router-dom'
-import { getProfile } from '../lib/api'
+import { getProfile, logoutUser } from '../lib/api'
 import { toast } from 'sonner'
 import { setCookie, getCookie, removeCookie } from '../utils/cookies'
 
 // Cookie name constant to ensure consistency
 const AUTH_TOKEN_COOKIE = 'auth_token';
+// Remembers whether the token cookie should outlive the browser session
+const REMEMBER_ME_KEY = 'auth_remember_me';
 
 interface AuthContextType {
   token: string | null
@@ -58,6 +60,23 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     };
   }, [navigate]);
 
+  // Keep the rotated access token after a refresh
+  useEffect(() => {
+    const handleRefreshed = (event: Event) => {
+      const t = (event as CustomEvent).detail?.token;
+      if (!t) return;
+      const expiryDays = localStorage.getItem(REMEMBER_ME_KEY) ? 30 : undefined;
+      setCookie(AUTH_TOKEN_COOKIE, t, expiryDays);
+      setToken(t);
+    };
+
+    window.addEventListener('auth:refreshed', handleRefreshed);
+
+    return () => {
+      window.removeEventListener('auth:refreshed', handleRefreshed);
+    };
+  }, []);
+
   useEffect(() => {
     if (token) {
       const payload = parseJwt(token);
@@ -86,6 +105,11 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
   const login = (t: string, rememberMe = false) => {
     // Set cookie with token - use days parameter for "remember me"
     const expiryDays = rememberMe ? 30 : undefined; // 30 days for "remember me", session cookie otherwise
+    if (rememberMe) {
+      localStorage.setItem(REMEMBER_ME_KEY, '1');
+    } else {
+      localStorage.removeItem(REMEMBER_ME_KEY);
+    }
     setCookie(AUTH_TOKEN_COOKIE, t, expiryDays);
     setToken(t);
   }
@@ -93,6 +117,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
   const handleLogout = () => {
     // Remove the auth cookie
     removeCookie(AUTH_TOKEN_COOKIE);
+    localStorage.removeItem(REMEMBER_ME_KEY);
     
     // Also remove the session cookie from the backend if it exists
     removeCookie('session');
@@ -101,13 +126,22 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     setRole(null);
   }
 
+  const logout = () => {
+    // The session is revoked on the server so the refresh token cannot be used again
+    if (token) {
+      logoutUser(token).catch(() => {});
+    }
+    handleLogout();
+  }
+
   return (
     <AuthContext.Provider value={{ 
       token, 
       login, 
-      logout: handleLogout, 
+      logout, 
       role,
-      isAdmin: role === 'admin'
+      // Панель управления доступна и авторам задач, права на задачи проверяет сервер
+      isAdmin: role === 'admin' || role === 'author'
     }}>
       {children}
     </AuthContext.Provider>
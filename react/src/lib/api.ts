@@ -66,7 +66,35 @@ function handleAuthError(errorText: string): boolean {
   return false;
 }
 
-export async function request(url: string, options: RequestInit = {}, token?: string) {
+// Concurrent requests share one refresh: a refresh token may be used only once
+let refreshing: Promise<string | null> | null = null;
+
+/**
+ * Exchange the refresh token cookie for a new access token.
+ * Resolves to null if the session has ended.
+ */
+export function refreshSession(): Promise<string | null> {
+  if (!refreshing) {
+    refreshing = fetch(`${BASE_URL}/auth/refresh`, {
+      method: "POST",
+      credentials: "include",
+      headers: { "Content-Type": "application/json" },
+    })
+      .then(async (res) => {
+        if (!res.ok) return null;
+        const data = await res.json();
+        window.dispatchEvent(new CustomEvent('auth:refreshed', { detail: { token: data.token } }));
+        return data.token as string;
+      })
+      .catch(() => null)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+}
+
+export async function request(url: string, options: RequestInit = {}, token?: string, retry = true): Promise<any> {
   const headers: HeadersInit = {
     "Content-Type": "application/json",
     ...(token && { Authorization: `Bearer ${token}` }),
@@ -79,6 +107,14 @@ export async function request(url: string, options: RequestInit = {}, token?: st
       credentials: "include", // This ensures cookies are sent with the request
       headers 
     });
+
+    // The access token is short-lived: refresh it once and repeat the request
+    if (res.status === 401 && token && retry) {
+      const newToken = await refreshSession();
+      if (newToken) {
+        return request(url, options, newToken, false);
+      }
+    }
     
     if (!res.ok) {
       const errorText = await res.text();
@@ -127,6 +163,7 @@ export const loginUser = async (username: string, password: string) => {
   try {
     const response = await fetch(`${BASE_URL}/auth/login`, {
       method: 'POST',
+      credentials: 'include', // the refresh token is stored in an httpOnly cookie
       headers: {
         'Content-Type': 'application/json',
       },
@@ -146,6 +183,11 @@ export const loginUser = async (username: string, password: string) => {
   }
 };
 
+// Ends the current session on the server; the refresh token cookie is cleared too
+export const logoutUser = async (token: string) => {
+  return request("/auth/logout", { method: "POST" }, token);
+};
+
 export const signUpUser = async (username: string, password: string) => {
   try {
     const response = await fetch(`${BASE_URL}/auth/signup`, {
@@ -171,7 +213,17 @@ export const signUpUser = async (username: string, password: string) => {
 
 export const getProblems = async (token: string) => {
   try {
-    return await request("/problems", {}, token);
+    // The list is paginated with a cursor, collect every page
+    const problems = [];
+    let cursor = "";
+    do {
+      const params = new URLSearchParams({ limit: "100" });
+      if (cursor) params.set("cursor", cursor);
+      const page = await request(`/problems?${params}`, {}, token);
+      problems.push(...page.problems);
+      cursor = page.next_cursor || "";
+    } while (cursor);
+    return problems;
   } catch (error) {
     if (error instanceof Error && error.message === 'Authentication required') {
       // This is already handled by the auth event
@@ -198,15 +250,23 @@ export const getProblem = async (id: string, token: string) => {
 
 export const submitSolution = async (id: string, payload: any, token: string) => {
   try {
-    const response = await fetch(`${BASE_URL}/problem/${id}`, {
+    const send = (t: string) => fetch(`${BASE_URL}/problem/${id}`, {
       method: "POST",
       headers: {
         "Content-Type": "application/json",
-        "Authorization": `Bearer ${token}`
+        "Authorization": `Bearer ${t}`
       },
       body: JSON.stringify(payload)
     });
 
+    let response = await send(token);
+    if (response.status === 401) {
+      const newToken = await refreshSession();
+      if (newToken) {
+        response = await send(newToken);
+      }
+    }
+
     const data = await response.json();
     
     // Even if the response indicates failure, return the data 
@@ -221,6 +281,18 @@ export const submitSolution = async (id: string, payload: any, token: string) =>
   }
 };
 
+export const saveDraft = async (id: string, language: string, code: string, token: string) => {
+  try {
+    return await request(`/problem/${id}/draft/${language}`, { method: "PUT", body: JSON.stringify({ code }) }, token);
+  } catch (error) {
+    if (error instanceof Error && error.message === 'Authentication required') {
+      throw error;
+    }
+    const errorMessage = extractErrorMessage(error);
+    throw new Error(`Не удалось сохранить черновик: ${errorMessage}`);
+  }
+};
+
 export const getDashboard = async (token: string) => {
   try {
     return await request("/admin/dashboard", {}, token);
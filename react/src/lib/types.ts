@@ -4,8 +4,25 @@ export interface Problem {
   id?: number
   name: string
   difficulty: 'easy' | 'medium' | 'hard'
-  description: string
+  description?: string
+  description_source?: string
+  input_format?: string
+  output_format?: string
+  locale?: string
+  locales?: string[]
+  status?: 'draft' | 'published' | 'archived'
+  rating?: number
+  tags?: string[]
+  acceptance_rate?: number
   solved?: boolean
+  max_score?: number
+  best_score?: number
+  starter_code?: Record<string, string>
+  drafts?: {
+    language: string
+    code: string
+    updated_at: string
+  }[]
   solution?: {
     average_time_ms: number
     average_memory_kb: number
@@ -661,7 +661,7 @@ const handleAddTestCase = async (input: string, output: string) => {
                       </div>
                       
                       <div>
-                        <label htmlFor="description" className="block text-sm font-medium text-gray-700 mb-1">Описание (HTML)</label>
+                        <label htmlFor="description" className="block text-sm font-medium text-gray-700 mb-1">Описание (Markdown, формулы в $...$ и $$...$$)</label>
                         <textarea
                           id="description"
                           value={newProblem.description}
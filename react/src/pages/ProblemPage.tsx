@@ -1,6 +1,6 @@
 import { useParams } from 'react-router-dom'
 import { useEffect, useState, useRef } from 'react'
-import { getProblem, submitSolution } from '../lib/api'
+import { getProblem, saveDraft, submitSolution } from '../lib/api'
 import { useAuth } from '../context/AuthContext'
 import { Button } from '@/components/ui/button'
 import Editor from '@monaco-editor/react'
@@ -35,6 +35,27 @@ export default function ProblemPage() {
   const [submitting, setSubmitting] = useState(false)
   const [activeTab, setActiveTab] = useState<TabType>('problem')
   
+  // Автосохранение черновика через секунду после последнего изменения
+  const draftTimer = useRef<ReturnType<typeof setTimeout> | null>(null)
+  const pendingDraft = useRef<{ language: string; code: string } | null>(null)
+  const flushDraft = () => {
+    if (draftTimer.current) clearTimeout(draftTimer.current)
+    draftTimer.current = null
+    const pending = pendingDraft.current
+    pendingDraft.current = null
+    if (uuid && pending) {
+      saveDraft(uuid, pending.language, pending.code, token || '').catch(console.error)
+    }
+  }
+  const scheduleDraftSave = (lang: string, code: string) => {
+    // Черновик другого языка сохраняем сразу, чтобы не потерять
+    if (pendingDraft.current && pendingDraft.current.language !== lang) flushDraft()
+    pendingDraft.current = { language: lang, code }
+    if (draftTimer.current) clearTimeout(draftTimer.current)
+    draftTimer.current = setTimeout(flushDraft, 1000)
+  }
+  useEffect(() => flushDraft, [uuid])
+
   // Фиксированное соотношение из констант
   const containerRef = useRef<HTMLDivElement>(null)
   const rightPanelRef = useRef<HTMLDivElement>(null)
@@ -44,17 +65,32 @@ export default function ProblemPage() {
     getProblem(uuid, token || '')
       .then((data) => {
         setProblem(data)
+
+        // Шаблоны задачи, поверх них — автосохранённые черновики
+        setCodes(prevCodes => {
+          const next = { ...prevCodes, ...(data.starter_code || {}) }
+          for (const draft of data.drafts || []) {
+            next[draft.language] = draft.code
+          }
+          return next
+        })
+        if (data.drafts && data.drafts.length > 0) {
+          setLanguage(data.drafts[0].language as 'python' | 'cpp' | 'java')
+        }
         
         // Если задача уже решена, устанавливаем язык и код из сохраненного решения
         if (data.solved && data.solution) {
           const solutionLanguage = data.solution.language as 'python' | 'cpp' | 'java'
           setLanguage(solutionLanguage)
           
-          // Обновляем код только для данного языка
-          setCodes(prevCodes => ({
-            ...prevCodes,
-            [solutionLanguage]: data.solution.code
-          }))
+          // Обновляем код только для данного языка, если нет более свежего черновика
+          const hasDraft = (data.drafts || []).some((d: { language: string }) => d.language === solutionLanguage)
+          if (!hasDraft) {
+            setCodes(prevCodes => ({
+              ...prevCodes,
+              [solutionLanguage]: data.solution.code
+            }))
+          }
           
           // Формируем объект output на основе данных из решения, включая сравнительную статистику
           setOutput({
@@ -302,6 +338,7 @@ export default function ProblemPage() {
                       ...prevCodes,
                       [language]: v || ''
                     }))
+                    scheduleDraftSave(language, v || '')
                   }}
                   theme="vs-light"
                   options={{
@@ -47,6 +47,8 @@ interface Problem {
   difficulty: string;
   description: string;
   solved: boolean;
+  max_score: number;
+  best_score: number;
 }
 
 interface Solution {
@@ -398,7 +400,7 @@ export default function ProfilePage() {
                 "bg-red-50 text-red-700 border-red-200" : 
                 "bg-blue-50 text-blue-700 border-blue-200"
               }>
-                {profileData.role === 'admin' ? 'Администратор' : 'Пользователь'}
+                {profileData.role === 'admin' ? 'Администратор' : profileData.role === 'author' ? 'Автор задач' : 'Пользователь'}
               </Badge>
               <span className="text-gray-500 text-sm">ID: {profileData.userID || 'N/A'}</span>
             </div>
@@ -814,6 +816,8 @@ export default function ProfilePage() {
                                   <div className="col-span-2 text-center">
                                     {problem.solved ? (
                                       <Badge variant="success">Решено</Badge>
+                                    ) : problem.best_score > 0 ? (
+                                      <Badge variant="warning">{problem.best_score}/{problem.max_score}</Badge>
                                     ) : (
                                       <Badge variant="warning">Попытка</Badge>
                                     )}